@@ -0,0 +1,179 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BlockSize is the number of bytes in a block, they are shown as 16 lines of
+// 64 characters.
+const BlockSize = 1024
+
+const (
+	blockBuffers = 4
+	blockLines   = 16
+	blockLine    = BlockSize / blockLines
+)
+
+// blockBuffer is a block sized area of data space holding the contents of a
+// block, dirty when it has been updated but not yet written to the file.
+type blockBuffer struct {
+	block int
+	addr  int
+	dirty bool
+}
+
+// blocks stores blocks in a file, block 1 being the first 1024 bytes of it,
+// using a few buffers in data space to hold the blocks being worked on.
+type blocks struct {
+	file    *os.File
+	buffers []blockBuffer
+
+	// current is the buffer most recently returned by block or buffer,
+	// next is the buffer to reuse next
+	current int
+	next    int
+}
+
+// SetBlockFile opens the file used by the block words, creating it if it
+// doesn't exist, and reserves data space for the block buffers.
+func (i *Interpreter) SetBlockFile(name string) error {
+	if err := i.FlushBlocks(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return err
+	}
+	if i.blocks != nil {
+		_ = i.blocks.file.Close()
+	}
+
+	i.memory.Align()
+	b := &blocks{file: f, current: -1}
+	for range blockBuffers {
+		b.buffers = append(b.buffers, blockBuffer{addr: i.memory.Here()})
+		_ = i.memory.Allot(BlockSize)
+	}
+	i.blocks = b
+	return nil
+}
+
+// FlushBlocks writes any updated blocks to the block file.
+func (i *Interpreter) FlushBlocks() error {
+	if i.blocks == nil {
+		return nil
+	}
+	for n := range i.blocks.buffers {
+		if err := i.saveBuffer(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignBuffer returns the index of the buffer holding block u, reading the
+// block from the file if read is true and it isn't already in a buffer.
+func (i *Interpreter) assignBuffer(u int, read bool) (int, error) {
+	if i.blocks == nil {
+		return 0, errors.New("no block file, run with -blocks")
+	}
+	if u < 1 {
+		return 0, fmt.Errorf("invalid block number %d", u)
+	}
+
+	for n, buffer := range i.blocks.buffers {
+		if buffer.block == u {
+			i.blocks.current = n
+			return n, nil
+		}
+	}
+
+	n := i.blocks.next
+	i.blocks.next = (n + 1) % len(i.blocks.buffers)
+	if err := i.saveBuffer(n); err != nil {
+		return 0, err
+	}
+	buffer := &i.blocks.buffers[n]
+	buffer.block = u
+	i.blocks.current = n
+
+	if read {
+		// blocks past the end of the file are blank
+		b := []byte(strings.Repeat(" ", BlockSize))
+		_, err := i.blocks.file.ReadAt(b, int64(u-1)*BlockSize)
+		if err != nil && err != io.EOF {
+			buffer.block = 0
+			return 0, err
+		}
+		if err := i.memory.Write(buffer.addr, b); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// saveBuffer writes the buffer to the block file if it has been updated.
+func (i *Interpreter) saveBuffer(n int) error {
+	buffer := &i.blocks.buffers[n]
+	if !buffer.dirty {
+		return nil
+	}
+	b, err := i.memory.Read(buffer.addr, BlockSize)
+	if err != nil {
+		return err
+	}
+	if _, err := i.blocks.file.WriteAt(b, int64(buffer.block-1)*BlockSize); err != nil {
+		return err
+	}
+	buffer.dirty = false
+	return nil
+}
+
+// emptyBuffers unassigns all the buffers, discarding any updates.
+func (i *Interpreter) emptyBuffers() {
+	if i.blocks == nil {
+		return
+	}
+	for n := range i.blocks.buffers {
+		i.blocks.buffers[n].block = 0
+		i.blocks.buffers[n].dirty = false
+	}
+	i.blocks.current = -1
+}
+
+// blockText returns the contents of block u.
+func (i *Interpreter) blockText(u int) (string, error) {
+	n, err := i.assignBuffer(u, true)
+	if err != nil {
+		return "", err
+	}
+	b, err := i.memory.Read(i.blocks.buffers[n].addr, BlockSize)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// loadBlock interprets the contents of block u, as the 16 lines it is shown
+// as.
+func (i *Interpreter) loadBlock(u int) error {
+	text, err := i.blockText(u)
+	if err != nil {
+		return err
+	}
+	lines := make([]string, blockLines)
+	for line := range lines {
+		lines[line] = text[line*blockLine : (line+1)*blockLine]
+	}
+	i.pushSource(newEnvironment(fmt.Sprintf("block %d", u), strings.Join(lines, "\n"), 1))
+	for i.environments[len(i.environments)-1].Scan() {
+		t := i.environments[len(i.environments)-1].Text()
+		i.Interpret(t)
+	}
+	i.popSource()
+	return nil
+}
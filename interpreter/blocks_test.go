@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	tests := map[string]struct {
+		blocks         string
+		input          string
+		expectedOutput string
+		expectedStack  []int
+		expectedBlocks string
+	}{
+		"load a block": {
+			blocks:         strings.Repeat(" ", BlockSize) + "1 2 +",
+			input:          "2 load",
+			expectedOutput: "",
+			expectedStack:  []int{3},
+		},
+		"a comment ends at the end of a line of a block": {
+			blocks:         "\\ comment" + strings.Repeat(" ", blockLine-9) + ".( loaded) cr",
+			input:          "1 load",
+			expectedOutput: "loaded\n",
+			expectedStack:  []int{},
+		},
+		"thru": {
+			blocks:         "1" + strings.Repeat(" ", BlockSize-1) + "2",
+			input:          "1 2 thru",
+			expectedOutput: "",
+			expectedStack:  []int{1, 2},
+		},
+		"blocks past the end are blank": {
+			input:          "3 block c@",
+			expectedOutput: "",
+			expectedStack:  []int{32},
+		},
+		"update and flush": {
+			blocks:         "abc",
+			input:          "65 1 block c! update flush 1 block c@",
+			expectedOutput: "",
+			expectedStack:  []int{65},
+			expectedBlocks: "Abc",
+		},
+		"updates are discarded by empty-buffers": {
+			blocks:         "abc",
+			input:          "65 1 block c! update empty-buffers save-buffers 1 block c@",
+			expectedOutput: "",
+			expectedStack:  []int{97},
+			expectedBlocks: "abc",
+		},
+		"buffer doesn't read the block": {
+			blocks:         "abc",
+			input:          "1 buffer 1 block =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+			expectedBlocks: "abc",
+		},
+		"list": {
+			blocks:         "hello" + strings.Repeat(" ", blockLine-5) + "world",
+			input:          "1 list",
+			expectedOutput: "block 1\n 0 hello\n 1 world\n",
+			expectedStack:  []int{},
+		},
+		"invalid block number": {
+			input:          "0 block",
+			expectedOutput: "invalid block number 0",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "blocks.fb")
+			if err := os.WriteFile(name, []byte(test.blocks), 0o666); err != nil {
+				t.Fatal(err)
+			}
+
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			if err := interpreter.SetBlockFile(name); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+			if err := interpreter.FlushBlocks(); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if !strings.HasPrefix(o.String(), test.expectedOutput) {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+
+			if test.expectedBlocks != "" {
+				b, err := os.ReadFile(name)
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				if !strings.HasPrefix(string(b), test.expectedBlocks) {
+					t.Errorf("expected blocks to start with %q, got %q", test.expectedBlocks, b[:10])
+				}
+			}
+		})
+	}
+}
+
+func TestBlocksWithoutFile(t *testing.T) {
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, "1 block")
+	w, _ := interpreter.Word()
+	interpreter.Interpret(w)
+	w, _ = interpreter.Word()
+	interpreter.Interpret(w)
+
+	if !strings.Contains(o.String(), "no block file") {
+		t.Errorf("expected a missing block file error, got '%v'", o.String())
+	}
+}
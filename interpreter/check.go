@@ -0,0 +1,384 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Problem is something wrong with the stack effects of a file found by
+// Check.
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+// stackEffect is the number of items a word takes from the stack and the
+// number it leaves.
+type stackEffect struct {
+	in  int
+	out int
+}
+
+// doubles are the items of a stack effect that take two cells.
+var doubles = regexp.MustCompile(`^\+?u?d\d*$`)
+
+// parseEffect returns the effect described by a stack effect comment, false
+// if the number of items isn't fixed, as in ( i*x xt -- j*x ) or
+// ( n -- 0 | n n ).
+func parseEffect(comment string) (stackEffect, bool) {
+	comment = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(comment), "("), ")")
+	before, after, found := strings.Cut(comment, "--")
+	if !found {
+		return stackEffect{}, false
+	}
+	count := func(items string) (int, bool) {
+		n := -1
+		for _, alternative := range strings.Split(items, "|") {
+			m := 0
+			for _, item := range strings.Fields(alternative) {
+				switch {
+				case strings.HasPrefix(item, "\""):
+					// text read from the input
+				case strings.HasSuffix(item, "*x") || strings.HasPrefix(item, ".."):
+					return 0, false
+				case doubles.MatchString(item):
+					m += 2
+				default:
+					m++
+				}
+			}
+			if n != -1 && m != n {
+				return 0, false
+			}
+			n = m
+		}
+		return n, true
+	}
+	in, ok := count(before)
+	if !ok {
+		return stackEffect{}, false
+	}
+	out, ok := count(after)
+	if !ok {
+		return stackEffect{}, false
+	}
+	return stackEffect{in: in, out: out}, true
+}
+
+// checker follows the stack through the words of the files being checked.
+type checker struct {
+	i        *Interpreter
+	problems []Problem
+
+	// effects are the effects of the words defined by the files checked,
+	// nil when it isn't known
+	effects map[string]*stackEffect
+	checked map[string]bool
+}
+
+// simulation is the state of the stack while following a definition or the
+// code outside definitions.
+type simulation struct {
+	// depth is the number of items on the stack compared to the start,
+	// lowest the lowest it has been
+	depth  int
+	lowest int
+
+	// available is the number of items on the stack at the start, or -1 if
+	// it isn't known and the items needed are being inferred
+	available int
+	name      string
+	self      *stackEffect
+	locals    map[string]bool
+
+	// failed is set once a problem has been reported, so there is only one
+	// for each definition
+	failed bool
+}
+
+// Check reads the named file, and the files it includes, without running
+// them, reporting the definitions whose stack effects don't match their stack
+// effect comments, ifs whose branches leave different numbers of items and
+// words that are sure to underflow the stack. The effects of the words
+// already defined are taken from their stack effect comments.
+func (i *Interpreter) Check(name string) ([]Problem, error) {
+	c := &checker{
+		i:       i,
+		effects: make(map[string]*stackEffect),
+		checked: make(map[string]bool),
+	}
+	if err := c.file(name); err != nil {
+		return nil, err
+	}
+	return c.problems, nil
+}
+
+func (c *checker) problem(t sourceToken, format string, args ...any) {
+	c.problems = append(c.problems, Problem{
+		File:    t.file,
+		Line:    t.line,
+		Column:  t.column + 1,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// file checks a file, the code outside definitions starts with an empty
+// stack.
+func (c *checker) file(name string) error {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	if c.checked[abs] {
+		return nil
+	}
+	c.checked[abs] = true
+	source, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tokens := sourceTokens(string(source), name, 1, 0)
+	top := &simulation{available: 0, locals: map[string]bool{}}
+	for n := 0; n < len(tokens); n++ {
+		t := tokens[n]
+		switch t.text {
+		case ":":
+			end := n + 1
+			for end < len(tokens) && tokens[end].text != ";" {
+				end++
+			}
+			c.definition(t, tokens[n+1:end])
+			n = end
+			continue
+		case "include", "require":
+			if included, ok := c.find(name, t.arg); ok {
+				if err := c.file(included); err != nil {
+					return err
+				}
+			}
+		case "variable", "create":
+			c.effects[t.arg] = &stackEffect{out: 1}
+		case "constant":
+			c.effects[t.arg] = &stackEffect{out: 1}
+		case "defer", "marker", "vocabulary", "begin-structure", "+field", "field:", "cfield:", "ffield:":
+			c.effects[t.arg] = nil
+		}
+		if !top.failed {
+			var ok bool
+			if n, ok = c.step(tokens, n, top); !ok {
+				top.failed = true
+			}
+		}
+	}
+	return nil
+}
+
+// find returns the file an include names, looking next to the file
+// including it first.
+func (c *checker) find(from string, name string) (string, bool) {
+	for _, extension := range extensions {
+		path := filepath.Join(filepath.Dir(from), name+extension)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	file, err := c.i.findFile(name)
+	if err != nil || file.embedded {
+		return "", false
+	}
+	return file.name, true
+}
+
+// definition checks a colon definition, comparing its effect with its stack
+// effect comment if it has one, and records its effect.
+func (c *checker) definition(colon sourceToken, body []sourceToken) {
+	s := &simulation{available: -1, name: colon.arg, locals: map[string]bool{}}
+	var declared *stackEffect
+	if len(body) > 0 && body[0].text == "(" {
+		comment := "( " + body[0].arg + " )"
+		if strings.Contains(body[0].arg, "--") {
+			effect, ok := parseEffect(comment)
+			if !ok {
+				// the number of items it takes or leaves varies
+				c.effects[colon.arg] = nil
+				return
+			}
+			declared = &effect
+			s.available = effect.in
+			s.self = declared
+		}
+	}
+
+	// the definition's name refers to any earlier word with the name, it
+	// calls itself with recurse
+	ok := c.run(body, 0, s) == len(body)
+	switch {
+	case declared != nil:
+		c.effects[colon.arg] = declared
+		if ok && !s.failed && s.depth != declared.out-declared.in {
+			in := max(-s.lowest, declared.in)
+			comment := strings.Join(strings.Fields("( "+body[0].arg+" )"), " ")
+			c.problem(colon, "%s takes %d and leaves %d, but %s takes %d and leaves %d",
+				colon.arg, in, in+s.depth, comment, declared.in, declared.out)
+		}
+	case ok && !s.failed:
+		c.effects[colon.arg] = &stackEffect{in: -s.lowest, out: s.depth - s.lowest}
+	default:
+		c.effects[colon.arg] = nil
+	}
+}
+
+// run follows the stack through the words from tokens[n] up to one of the
+// words ending a control structure, returning its index. It returns -1 if
+// the stack can't be followed, as when a word's effect isn't known.
+func (c *checker) run(tokens []sourceToken, n int, s *simulation) int {
+	for n < len(tokens) && !structureWords[tokens[n].text] {
+		var ok bool
+		if n, ok = c.step(tokens, n, s); !ok {
+			return -1
+		}
+		n++
+	}
+	return n
+}
+
+// step follows the stack through tokens[n], along with the rest of the
+// control structure it starts, returning the index of its last word and false
+// if the stack can't be followed.
+func (c *checker) step(tokens []sourceToken, n int, s *simulation) (int, bool) {
+	t := tokens[n]
+	if s.locals[t.text] {
+		return n, c.apply(t, s, stackEffect{out: 1})
+	}
+	if _, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+		return n, c.apply(t, s, stackEffect{out: 1})
+	}
+
+	switch t.text {
+	case "if":
+		if !c.apply(t, s, stackEffect{in: 1}) {
+			return n, false
+		}
+		taken := *s
+		end := c.run(tokens, n+1, &taken)
+		if end == -1 || taken.failed {
+			s.failed = taken.failed
+			return n, false
+		}
+		skipped := *s
+		if end < len(tokens) && tokens[end].text == "else" {
+			if end = c.run(tokens, end+1, &skipped); end == -1 || skipped.failed {
+				s.failed = skipped.failed
+				return n, false
+			}
+		}
+		if taken.depth != skipped.depth {
+			c.problem(t, "the branches of if leave different numbers of items, %+d and %+d",
+				taken.depth-s.depth, skipped.depth-s.depth)
+			s.failed = true
+			return n, false
+		}
+		s.depth = taken.depth
+		s.lowest = min(taken.lowest, skipped.lowest)
+		return end, true
+	case "do":
+		if !c.apply(t, s, stackEffect{in: 2}) {
+			return n, false
+		}
+		body := *s
+		end := c.run(tokens, n+1, &body)
+		if end == -1 || body.failed {
+			s.failed = body.failed
+			return n, false
+		}
+		// the number of items left by a body that changes the depth
+		// depends on the number of times round the loop
+		if body.depth != s.depth {
+			return n, false
+		}
+		s.lowest = body.lowest
+		return end, true
+	case "[:":
+		// the quotation's execution token is pushed rather than run
+		depth := 0
+		for end := n + 1; end < len(tokens); end++ {
+			if tokens[end].text == "[:" {
+				depth++
+			} else if tokens[end].text == ";]" {
+				if depth == 0 {
+					return end, c.apply(t, s, stackEffect{out: 1})
+				}
+				depth--
+			}
+		}
+		return n, false
+	case "{:":
+		args, _, _ := strings.Cut(t.arg, "--")
+		names := strings.Fields(strings.Replace(args, "|", " ", 1))
+		initialized, _, _ := strings.Cut(args, "|")
+		for _, name := range names {
+			s.locals[name] = true
+		}
+		return n, c.apply(t, s, stackEffect{in: len(strings.Fields(initialized))})
+	case "recurse":
+		if s.self == nil {
+			return n, false
+		}
+		return n, c.apply(t, s, *s.self)
+	case "to":
+		if s.locals[t.arg] {
+			return n, c.apply(t, s, stackEffect{in: 1})
+		}
+	}
+
+	effect, ok := c.effect(t.text)
+	if !ok {
+		return n, false
+	}
+	return n, c.apply(t, s, effect)
+}
+
+// effect returns the effect of a word, false if it isn't known.
+func (c *checker) effect(name string) (stackEffect, bool) {
+	if effect, ok := c.effects[name]; ok {
+		if effect == nil {
+			return stackEffect{}, false
+		}
+		return *effect, true
+	}
+	word, ok := c.i.lookup(name)
+	if !ok {
+		return stackEffect{}, false
+	}
+	return parseEffect(word.effect)
+}
+
+// apply follows the stack through a word, reporting an underflow if the
+// items it needs can't be on the stack.
+func (c *checker) apply(t sourceToken, s *simulation, effect stackEffect) bool {
+	s.depth -= effect.in
+	s.lowest = min(s.lowest, s.depth)
+	if s.available >= 0 && s.depth < -s.available {
+		have := s.depth + effect.in + s.available
+		if s.name == "" {
+			c.problem(t, "stack underflow, %s needs %d but there are %d", t.text, effect.in, have)
+		} else {
+			c.problem(t, "stack underflow in %s, %s needs %d but there are %d", s.name, t.text, effect.in, have)
+		}
+		s.failed = true
+		return false
+	}
+	s.depth += effect.out
+	return true
+}
@@ -0,0 +1,116 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := map[string]struct {
+		files            map[string]string
+		expectedProblems []string
+	}{
+		"matching effects": {
+			files: map[string]string{"main.fs": ": square ( n -- n*n ) dup * ;\n" +
+				": sign ( n -- n ) dup 0 < if drop -1 else 0 > if 1 else 0 then then ;\n" +
+				": sum ( n -- total ) 0 swap 0 do i + loop ;\n" +
+				": 2square ( n1 n2 -- n3 n4 ) {: a b :} a square b square ;\n" +
+				"3 square drop"},
+			expectedProblems: nil,
+		},
+		"mismatched effect": {
+			files:            map[string]string{"main.fs": ": square ( n -- n*n ) dup dup * ;"},
+			expectedProblems: []string{"main.fs:1:1: square takes 1 and leaves 2, but ( n -- n*n ) takes 1 and leaves 1"},
+		},
+		"underflow in a definition": {
+			files:            map[string]string{"main.fs": ": add3 ( a b c -- sum )\n  + + + ;"},
+			expectedProblems: []string{"main.fs:2:7: stack underflow in add3, + needs 2 but there are 1"},
+		},
+		"underflow outside definitions": {
+			files:            map[string]string{"main.fs": "1 2 + +"},
+			expectedProblems: []string{"main.fs:1:7: stack underflow, + needs 2 but there are 1"},
+		},
+		"branches that disagree": {
+			files:            map[string]string{"main.fs": ": pick ( n -- n ) 0 < if 1 2 else 3 then ;"},
+			expectedProblems: []string{"main.fs:1:23: the branches of if leave different numbers of items, +2 and +1"},
+		},
+		"inferred effects": {
+			files:            map[string]string{"main.fs": ": squared dup * ;\n: use ( -- n ) squared ;"},
+			expectedProblems: []string{"main.fs:2:16: stack underflow in use, squared needs 1 but there are 0"},
+		},
+		"words with varying effects aren't checked": {
+			files: map[string]string{"main.fs": ": ?dup2 ( n -- 0 | n n ) dup 0 <> if dup then ;\n" +
+				": fibs 10 1 do over over + loop ;\n" +
+				": go ( n -- ) fibs ;"},
+			expectedProblems: nil,
+		},
+		"recurse uses the declared effect": {
+			files:            map[string]string{"main.fs": ": down ( n -- ) dup 0 > if 1 - recurse else drop then ;"},
+			expectedProblems: nil,
+		},
+		"included files": {
+			files: map[string]string{
+				"main.fs": "include lib.fs\n: twice ( n -- n ) double double double ;",
+				"lib.fs":  ": double ( n -- 2n ) 2 * ;\n: bad ( -- ) drop ;",
+			},
+			expectedProblems: []string{
+				"lib.fs:2:14: stack underflow in bad, drop needs 1 but there are 0",
+			},
+		},
+		"variables and constants": {
+			files:            map[string]string{"main.fs": "variable x 5 constant five\n: get ( -- n ) x @ five + ;"},
+			expectedProblems: nil,
+		},
+		"comments and strings": {
+			files:            map[string]string{"main.fs": ": hi ( -- ) .\" a + b\" ( + ) [char] + drop ;"},
+			expectedProblems: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(writeFiles(t, test.files))
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, "")
+			problems, err := interpreter.Check("main.fs")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			var got []string
+			for _, problem := range problems {
+				got = append(got, problem.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(test.expectedProblems, "\n") {
+				t.Errorf("expected %q, got %q", test.expectedProblems, got)
+			}
+		})
+	}
+}
+
+func TestParseEffect(t *testing.T) {
+	tests := map[string]struct {
+		effect   string
+		expected stackEffect
+		ok       bool
+	}{
+		"simple":      {"( n1 n2 -- sum )", stackEffect{in: 2, out: 1}, true},
+		"nothing":     {"( -- )", stackEffect{}, true},
+		"doubles":     {"( fileid -- ud ior )", stackEffect{in: 1, out: 3}, true},
+		"parsed text": {"( char \"ccc<char>\" -- c-addr u )", stackEffect{in: 1, out: 2}, true},
+		"alternatives with the same number of items": {"( c-addr -- c-addr 0 | xt 1 | xt -1 )", stackEffect{in: 1, out: 2}, true},
+		"alternatives with different numbers":        {"( n -- 0 | n n )", stackEffect{}, false},
+		"any number of items":                        {"( i*x xt -- j*x )", stackEffect{}, false},
+		"ranges":                                     {"( x1 .. xn -- )", stackEffect{}, false},
+		"not an effect":                              {"( a comment )", stackEffect{}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			effect, ok := parseEffect(test.effect)
+			if ok != test.ok || effect != test.expected {
+				t.Errorf("expected %v %v, got %v %v", test.expected, test.ok, effect, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package interpreter
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// coverage records the words run from the files included while it's
+// recording.
+type coverage struct {
+	hook *ExecutionHook
+
+	// sources are the files included while recording, files their names in
+	// the order they were included
+	sources map[string]string
+	files   []string
+
+	// hits counts the times each word written in the files was run, calls
+	// the times each definition was run
+	hits  map[position]int
+	calls map[*ExecutableToken]int
+
+	// branches counts the ways each if and do went
+	branches map[position]*branchCoverage
+
+	// frames are the definitions being run when the last word was run, to
+	// count the calls to them however they were run
+	frames []*frame
+}
+
+type branchCoverage struct {
+	word    string
+	taken   int
+	skipped int
+}
+
+// covered returns the interpreter's coverage.
+func (i *Interpreter) covered() *coverage {
+	if i.coverage == nil {
+		c := &coverage{}
+		c.hook = &ExecutionHook{Before: func(e Event) { i.coverageBefore(c, e) }}
+		c.reset()
+		i.coverage = c
+	}
+	return i.coverage
+}
+
+func (c *coverage) reset() {
+	c.sources = make(map[string]string)
+	c.files = nil
+	c.hits = make(map[position]int)
+	c.calls = make(map[*ExecutableToken]int)
+	c.branches = make(map[position]*branchCoverage)
+	c.frames = nil
+}
+
+// StartCoverage discards any coverage recorded and starts recording the
+// words run from the files included from now on.
+func (i *Interpreter) StartCoverage() {
+	c := i.covered()
+	c.reset()
+	i.setHook(c.hook, true)
+}
+
+// StopCoverage stops recording coverage, keeping what has been recorded.
+func (i *Interpreter) StopCoverage() {
+	if i.coverage != nil {
+		i.setHook(i.coverage.hook, false)
+	}
+}
+
+// coverFile records the source of a file being included, if coverage is
+// being recorded.
+func (i *Interpreter) coverFile(name string, source string) {
+	c := i.coverage
+	if c == nil || !i.hooked(c.hook) {
+		return
+	}
+	if _, ok := c.sources[name]; !ok {
+		c.files = append(c.files, name)
+	}
+	c.sources[name] = source
+}
+
+func (i *Interpreter) coverageBefore(c *coverage, e Event) {
+	for n, f := range i.frames.items {
+		if n >= len(c.frames) || c.frames[n] != f {
+			c.calls[f.word]++
+		}
+	}
+	c.frames = append(c.frames[:0], i.frames.items...)
+
+	if _, ok := c.sources[e.File]; !ok {
+		return
+	}
+	p := position{file: e.File, line: e.Line, column: e.Column}
+	c.hits[p]++
+
+	// if takes its branch for true, do runs its body if the index is below
+	// the limit
+	word, ok := i.eventWord(e)
+	if !ok || word.xt >= i.builtins || (word.name != "if" && word.name != "do") {
+		return
+	}
+	stack := i.stack.items
+	var taken bool
+	if word.name == "if" && len(stack) > 0 {
+		taken = stack[len(stack)-1] == -1
+	} else if word.name == "do" && len(stack) > 1 {
+		taken = stack[len(stack)-1] < stack[len(stack)-2]
+	} else {
+		return
+	}
+	b, ok := c.branches[p]
+	if !ok {
+		b = &branchCoverage{word: word.name}
+		c.branches[p] = b
+	}
+	if taken {
+		b.taken++
+	} else {
+		b.skipped++
+	}
+}
+
+// definitionTokens returns the words of a definition that run when it does,
+// leaving out comments and the words ending control structures.
+func definitionTokens(word *ExecutableToken) []sourceToken {
+	var tokens []sourceToken
+	for _, t := range sourceTokens(word.definition, word.file, word.first, word.column) {
+		if t.text != "(" && t.text != "\\" && !structureWords[t.text] {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// coverageWords returns the definitions in the files covered, in the order
+// they were defined.
+func (i *Interpreter) coverageWords() []*ExecutableToken {
+	var words []*ExecutableToken
+	for _, word := range i.xts {
+		if _, ok := i.covered().sources[word.file]; ok && word.colon && !strings.HasPrefix(word.name, "[: ") {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// coverageBranches returns the positions of the ifs and dos in the
+// definitions covered, and any others run, in the order they were written.
+// Those that never ran are added to the branches recorded, so they can be
+// reported.
+func (i *Interpreter) coverageBranches() []position {
+	c := i.covered()
+	var branches []position
+	for p := range c.branches {
+		branches = append(branches, p)
+	}
+	for _, word := range i.coverageWords() {
+		for _, t := range definitionTokens(word) {
+			if (t.text == "if" || t.text == "do") && c.branches[t.position] == nil {
+				c.branches[t.position] = &branchCoverage{word: t.text}
+				branches = append(branches, t.position)
+			}
+		}
+	}
+	slices.SortFunc(branches, func(a, b position) int {
+		return cmp.Or(cmp.Compare(slices.Index(c.files, a.file), slices.Index(c.files, b.file)),
+			cmp.Compare(a.line, b.line), cmp.Compare(a.column, b.column))
+	})
+	return branches
+}
+
+func percent(n int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// WriteCoverageReport writes the calls to each definition in the files
+// covered and how many of its words ran, then how each if and do went, then
+// the totals.
+func (i *Interpreter) WriteCoverageReport(w io.Writer) error {
+	c := i.covered()
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+
+	run, hit, total := 0, 0, 0
+	for _, word := range i.coverageWords() {
+		if c.calls[word] > 0 {
+			run++
+		}
+		tokens := definitionTokens(word)
+		n := 0
+		for _, t := range tokens {
+			if c.hits[t.position] > 0 {
+				n++
+			}
+		}
+		hit += n
+		total += len(tokens)
+		fmt.Fprintf(tw, "%s:%d:\t%s\t%d calls\t%.1f%%\n", word.file, word.line, word.name, c.calls[word], percent(n, len(tokens)))
+	}
+
+	branches := i.coverageBranches()
+	taken := 0
+	for _, p := range branches {
+		b := c.branches[p]
+		if b.taken > 0 {
+			taken++
+		}
+		if b.skipped > 0 {
+			taken++
+		}
+		yes, no := "true", "false"
+		if b.word == "do" {
+			yes, no = "ran", "skipped"
+		}
+		fmt.Fprintf(tw, "%s:\t%s\t%s %d\t%s %d\n", p, b.word, yes, b.taken, no, b.skipped)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "coverage: %.1f%% of words, %.1f%% of tokens, %.1f%% of branches\n",
+		percent(run, len(i.coverageWords())), percent(hit, total), percent(taken, 2*len(branches)))
+	return err
+}
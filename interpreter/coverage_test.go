@@ -0,0 +1,104 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+const coverageSource = `\ classify numbers
+: sign ( n -- -1|0|1 )
+  dup 0 < if drop -1 else
+    0 > if 1 else 0 then
+  then ;
+: sum ( n -- total ) 0 swap 0 do i + loop ;
+: unused ." never" ;
+-5 sign 3 sign 4 sum
+`
+
+func TestCoverageReport(t *testing.T) {
+	tests := map[string]struct {
+		files          map[string]string
+		expectedReport string
+	}{
+		"words and branches": {
+			files: map[string]string{"main.fs": coverageSource},
+			expectedReport: "main.fs:2:\tsign\t2 calls\t90.9%\n" +
+				"main.fs:6:\tsum\t1 calls\t100.0%\n" +
+				"main.fs:7:\tunused\t0 calls\t0.0%\n" +
+				"main.fs:3:11:\tif\ttrue 1\tfalse 1\n" +
+				"main.fs:4:9:\tif\ttrue 1\tfalse 0\n" +
+				"main.fs:6:31:\tdo\tran 1\tskipped 0\n" +
+				"coverage: 66.7% of words, 88.9% of tokens, 66.7% of branches\n",
+		},
+		"branches never reached": {
+			files: map[string]string{"main.fs": ": twice ( n -- ) 0 do 2 0 do i . loop loop ;"},
+			expectedReport: "main.fs:1:\ttwice\t0 calls\t0.0%\n" +
+				"main.fs:1:20:\tdo\tran 0\tskipped 0\n" +
+				"main.fs:1:27:\tdo\tran 0\tskipped 0\n" +
+				"coverage: 0.0% of words, 0.0% of tokens, 0.0% of branches\n",
+		},
+		"words run with execute": {
+			files: map[string]string{"main.fs": ": one 1 ;\n' one execute drop"},
+			expectedReport: "main.fs:1:\tone\t1 calls\t100.0%\n" +
+				"coverage: 100.0% of words, 100.0% of tokens, 0.0% of branches\n",
+		},
+		"included files": {
+			files: map[string]string{
+				"main.fs": "include lib.fs\n2 double drop",
+				"lib.fs":  ": double ( n -- 2n )\n  [char] * drop 2 * ;",
+			},
+			expectedReport: "lib.fs:1:\tdouble\t1 calls\t100.0%\n" +
+				"coverage: 100.0% of words, 100.0% of tokens, 0.0% of branches\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(writeFiles(t, test.files))
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, "")
+			interpreter.StartCoverage()
+			if err := interpreter.Include("main.fs"); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			interpreter.StopCoverage()
+
+			var report strings.Builder
+			if err := interpreter.WriteCoverageReport(&report); err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedReport != report.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedReport, report.String())
+			}
+		})
+	}
+}
+
+func TestCoverageHTML(t *testing.T) {
+	t.Chdir(writeFiles(t, map[string]string{"main.fs": coverageSource}))
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, "")
+	interpreter.StartCoverage()
+	if err := interpreter.Include("main.fs"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var page strings.Builder
+	if err := interpreter.WriteCoverageHTML(&page); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<h2>main.fs</h2>`,
+		`<span class="line">3</span>   <span class="run" title="2 runs">dup</span>`,
+		`<span class="run" title="2 runs, true 1, false 1">if</span>`,
+		`<span class="partial" title="1 runs, true 1, false 0">if</span>`,
+		`else <span class="unrun" title="0 runs">0</span> then`,
+		`<span class="run" title="4 runs">i</span>`,
+		`<span class="unrun" title="0 runs">.&#34;</span> never&#34; ;`,
+		`<span class="line">1</span> \ classify numbers`,
+	} {
+		if !strings.Contains(page.String(), expected) {
+			t.Errorf("expected the page to contain '%v', got '%v'", expected, page.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+)
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Forth coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { font-family: monospace; line-height: 1.4; }
+.line { color: #888; user-select: none; }
+.run { background: #c8f0c8; }
+.partial { background: #f8e8a0; }
+.unrun { background: #f8c8c8; }
+</style>
+</head>
+<body>
+<pre>{{.Summary}}</pre>
+{{range .Files}}<h2>{{.Name}}</h2>
+<pre>{{range .Lines}}{{.}}
+{{end}}</pre>
+{{end}}</body>
+</html>
+`))
+
+type coverageFile struct {
+	Name  string
+	Lines []template.HTML
+}
+
+// WriteCoverageHTML writes the coverage report followed by the source of the
+// files covered, with the words that ran in green, the ifs and dos that only
+// went one way in yellow and the words in definitions that didn't run in red.
+// Hovering over a word shows the times it ran.
+func (i *Interpreter) WriteCoverageHTML(w io.Writer) error {
+	c := i.covered()
+	var summary strings.Builder
+	if err := i.WriteCoverageReport(&summary); err != nil {
+		return err
+	}
+
+	runnable := make(map[position]bool)
+	for _, word := range i.coverageWords() {
+		for _, t := range definitionTokens(word) {
+			runnable[t.position] = true
+		}
+	}
+	i.coverageBranches()
+
+	var files []coverageFile
+	for _, name := range c.files {
+		file := coverageFile{Name: name}
+		lines := strings.Split(c.sources[name], "\n")
+		width := len(fmt.Sprint(len(lines)))
+		for n, line := range lines {
+			var b strings.Builder
+			fmt.Fprintf(&b, `<span class="line">%*d</span> `, width, n+1)
+			line = strings.TrimSuffix(line, "\r")
+			for p := 0; p < len(line); {
+				start := p
+				for p < len(line) && isSpace(line[p]) {
+					p++
+				}
+				b.WriteString(html.EscapeString(line[start:p]))
+				start = p
+				for p < len(line) && !isSpace(line[p]) {
+					p++
+				}
+				if start == p {
+					break
+				}
+				b.WriteString(c.token(position{file: name, line: n + 1, column: start}, line[start:p], runnable))
+			}
+			file.Lines = append(file.Lines, template.HTML(b.String()))
+		}
+		files = append(files, file)
+	}
+
+	return coverageTemplate.Execute(w, struct {
+		Summary string
+		Files   []coverageFile
+	}{summary.String(), files})
+}
+
+// token returns the HTML for a word in a file covered, comments are left as
+// they are.
+func (c *coverage) token(p position, text string, runnable map[position]bool) string {
+	if text == "(" || text == "\\" {
+		return text
+	}
+	text = html.EscapeString(text)
+	hits := c.hits[p]
+	if b, ok := c.branches[p]; ok && (b.taken > 0 || b.skipped > 0 || runnable[p]) {
+		yes, no := "true", "false"
+		if b.word == "do" {
+			yes, no = "ran", "skipped"
+		}
+		class := "run"
+		if b.taken == 0 || b.skipped == 0 {
+			class = "partial"
+		}
+		if hits == 0 {
+			class = "unrun"
+		}
+		return fmt.Sprintf(`<span class="%s" title="%d runs, %s %d, %s %d">%s</span>`, class, hits, yes, b.taken, no, b.skipped, text)
+	}
+	if hits > 0 {
+		return fmt.Sprintf(`<span class="run" title="%d runs">%s</span>`, hits, text)
+	}
+	if runnable[p] {
+		return fmt.Sprintf(`<span class="unrun" title="0 runs">%s</span>`, text)
+	}
+	return text
+}
@@ -0,0 +1,233 @@
+package interpreter
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The ways the debugger runs words between stops.
+const (
+	debugRun = iota
+	debugStep
+	debugOver
+)
+
+// breakpoint stops the debugger before a word is run, either one with the
+// given name or the first on a line. A line breakpoint without a file stops
+// on that line of any file.
+type breakpoint struct {
+	word string
+	file string
+	line int
+}
+
+func parseBreakpoint(spec string) breakpoint {
+	if n, err := strconv.Atoi(spec); err == nil {
+		return breakpoint{line: n}
+	}
+	if colon := strings.LastIndex(spec, ":"); colon > 0 {
+		if n, err := strconv.Atoi(spec[colon+1:]); err == nil {
+			return breakpoint{file: spec[:colon], line: n}
+		}
+	}
+	return breakpoint{word: spec}
+}
+
+func (b breakpoint) String() string {
+	switch {
+	case b.word != "":
+		return b.word
+	case b.file != "":
+		return fmt.Sprintf("%s:%d", b.file, b.line)
+	}
+	return strconv.Itoa(b.line)
+}
+
+// debugger stops before words are run to show the stacks and read commands.
+type debugger struct {
+	hook        *ExecutionHook
+	mode        int
+	depth       int
+	breakpoints []breakpoint
+
+	// file and line are where the last word run was written, so a line
+	// breakpoint only stops on the first word of the line
+	file string
+	line int
+}
+
+// debug returns the interpreter's debugger, adding its hook.
+func (i *Interpreter) debug() *debugger {
+	if i.debugger == nil {
+		d := &debugger{}
+		d.hook = &ExecutionHook{Before: func(e Event) { i.debugBefore(d, e) }}
+		i.debugger = d
+	}
+	if !i.hooked(i.debugger.hook) {
+		i.setHook(i.debugger.hook, true)
+	}
+	return i.debugger
+}
+
+// debugDone removes the debugger's hook when there are no breakpoints left
+// for it to stop at.
+func (i *Interpreter) debugDone() {
+	d := i.debugger
+	if d != nil && d.mode == debugRun && len(d.breakpoints) == 0 {
+		i.setHook(d.hook, false)
+	}
+}
+
+// debugWord runs a colon definition a word at a time.
+func (i *Interpreter) debugWord(word *ExecutableToken) {
+	if !word.colon {
+		panic(fmt.Sprintf("%s is a primitive\n", word.name))
+	}
+	d := i.debug()
+	d.mode = debugStep
+	defer func() {
+		d.mode = debugRun
+		i.debugDone()
+	}()
+	word.primitive()
+}
+
+func (i *Interpreter) addBreakpoint(spec string) {
+	d := i.debug()
+	b := parseBreakpoint(spec)
+	if !slices.Contains(d.breakpoints, b) {
+		d.breakpoints = append(d.breakpoints, b)
+	}
+}
+
+func (i *Interpreter) removeBreakpoint(spec string) {
+	if i.debugger == nil {
+		return
+	}
+	b := parseBreakpoint(spec)
+	i.debugger.breakpoints = slices.DeleteFunc(i.debugger.breakpoints, func(o breakpoint) bool {
+		return o == b
+	})
+	i.debugDone()
+}
+
+func (i *Interpreter) printBreakpoints() {
+	if i.debugger == nil {
+		return
+	}
+	for _, b := range i.debugger.breakpoints {
+		_, err := fmt.Fprintln(i.out, b)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// debugBefore is the debugger's hook, stopping before the word is run when
+// stepping or at a breakpoint.
+func (i *Interpreter) debugBefore(d *debugger, e Event) {
+	newLine := e.File != d.file || e.Line != d.line
+	d.file, d.line = e.File, e.Line
+
+	stop := d.mode == debugStep || (d.mode == debugOver && e.Depth <= d.depth)
+	for _, b := range d.breakpoints {
+		if b.word != "" {
+			stop = stop || b.word == e.Word
+		} else {
+			stop = stop || (newLine && b.line == e.Line && (b.file == "" || b.file == e.File))
+		}
+	}
+	if stop {
+		i.debugStop(d, e)
+	}
+}
+
+// debugStop shows where the debugger has stopped and reads commands until
+// one runs more words.
+func (i *Interpreter) debugStop(d *debugger, e Event) {
+	var b strings.Builder
+	if e.File != "" {
+		fmt.Fprintf(&b, "%s:", e.File)
+	}
+	fmt.Fprintf(&b, "%d: ", e.Line)
+	for _, name := range i.Calls() {
+		fmt.Fprintf(&b, "%s > ", name)
+	}
+	fmt.Fprintf(&b, "%s\n%s\n", e.Word, i.formatStack())
+	if len(i.loopStack.items) > 0 {
+		b.WriteString("loop:")
+		for _, v := range i.loopStack.items {
+			fmt.Fprintf(&b, " %d", v)
+		}
+		b.WriteString("\n")
+	}
+	_, err := fmt.Fprint(i.out, b.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for {
+		_, err := fmt.Fprint(i.out, "debug> ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		line, err := i.readInput()
+		if err != nil {
+			_, err := fmt.Fprintln(i.out)
+			if err != nil {
+				log.Fatal(err)
+			}
+			d.mode = debugRun
+			return
+		}
+		command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case "", "s", "step":
+			d.mode = debugStep
+			return
+		case "n", "next":
+			d.mode = debugOver
+			d.depth = e.Depth
+			return
+		case "c", "continue":
+			d.mode = debugRun
+			return
+		case "b", "break":
+			if arg == "" {
+				i.printBreakpoints()
+			} else {
+				i.addBreakpoint(arg)
+			}
+		case "d", "delete":
+			d.breakpoints = slices.DeleteFunc(d.breakpoints, func(o breakpoint) bool {
+				return arg == "" || o == parseBreakpoint(arg)
+			})
+		case "q", "quit":
+			d.mode = debugRun
+			panic(errInterrupted)
+		case "h", "help":
+			_, err := fmt.Fprint(i.out, debugHelp)
+			if err != nil {
+				log.Fatal(err)
+			}
+		default:
+			_, err := fmt.Fprintf(i.out, "%s ? (h for help)\n", command)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+const debugHelp = `s, step       run the next word, stepping into definitions
+n, next       run the next word, stepping over definitions
+c, continue   run to the next breakpoint
+b, break [x]  stop at the word or [file:]line x, or list the breakpoints
+d, delete [x] remove the breakpoint x, or all of them
+q, quit       abandon the words being run
+h, help       show the commands
+`
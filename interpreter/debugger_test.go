@@ -0,0 +1,139 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebugger(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		commands       string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"step into": {
+			input:    ": square dup * ;\n: cube dup square * ;\n3 debug cube",
+			commands: "s\ns\n\ns\ns\n",
+			expectedOutput: "2: cube > dup\n<1> 3\ndebug> " +
+				"2: cube > square\n<2> 3 3\ndebug> " +
+				"1: cube > square > dup\n<2> 3 3\ndebug> " +
+				"1: cube > square > *\n<3> 3 3 3\ndebug> " +
+				"2: cube > *\n<2> 3 9\ndebug> ",
+			expectedStack: []int{27},
+		},
+		"step over": {
+			input:    ": square dup * ;\n: cube dup square * ;\n3 debug cube",
+			commands: "n\nn\nn\n",
+			expectedOutput: "2: cube > dup\n<1> 3\ndebug> " +
+				"2: cube > square\n<2> 3 3\ndebug> " +
+				"2: cube > *\n<2> 3 9\ndebug> ",
+			expectedStack: []int{27},
+		},
+		"continue": {
+			input:          ": square dup * ;\n3 debug square 2 square",
+			commands:       "c\n",
+			expectedOutput: "1: square > dup\n<1> 3\ndebug> ",
+			expectedStack:  []int{9, 4},
+		},
+		"loop stack": {
+			input:          ": counter 2 0 do i loop ;\ndebug counter",
+			commands:       "s\ns\ns\nc\n",
+			expectedOutput: "1: counter > 2\n<0>\ndebug> 1: counter > 0\n<1> 2\ndebug> 1: counter > do\n<2> 2 0\ndebug> 1: counter > i\n<0>\nloop: 0\ndebug> ",
+			expectedStack:  []int{0, 1},
+		},
+		"word breakpoint": {
+			input:          ": square dup * ;\n: cube dup square * ;\nbreak square 2 cube",
+			commands:       "c\n",
+			expectedOutput: "2: cube > square\n<2> 2 2\ndebug> ",
+			expectedStack:  []int{8},
+		},
+		"line breakpoint": {
+			input:          ": cube\n  dup\n  dup * * ;\nbreak 3 2 cube unbreak 3 3 cube",
+			commands:       "c\n",
+			expectedOutput: "3: cube > dup\n<2> 2 2\ndebug> ",
+			expectedStack:  []int{8, 27},
+		},
+		"breakpoints": {
+			input:          "break square break test.fs:3 break 4 unbreak 4 breakpoints",
+			expectedOutput: "square\ntest.fs:3\n",
+			expectedStack:  []int{},
+		},
+		"setting breakpoints while stopped": {
+			input:          ": square dup * ;\n: cube dup square * ;\n2 debug cube",
+			commands:       "b *\nb\nc\nd\nc\n",
+			expectedOutput: "2: cube > dup\n<1> 2\ndebug> debug> *\ndebug> 1: cube > square > *\n<3> 2 2 2\ndebug> debug> ",
+			expectedStack:  []int{8},
+		},
+		"quit": {
+			input:          ": square dup * ;\n3 debug square 4",
+			commands:       "q\n",
+			expectedOutput: "1: square > dup\n<1> 3\ndebug> interrupted\n",
+			expectedStack:  []int{},
+		},
+		"end of input continues": {
+			input:          ": square dup * ;\n3 debug square",
+			commands:       "",
+			expectedOutput: "1: square > dup\n<1> 3\ndebug> \n",
+			expectedStack:  []int{9},
+		},
+		"primitive": {
+			input:          "debug dup",
+			expectedOutput: "dup is a primitive\n",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input, WithInput(strings.NewReader(test.commands)))
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestExecutionHooks(t *testing.T) {
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, ": square\n  dup * ;\n3 square")
+	var events []Event
+	hook := &ExecutionHook{Before: func(e Event) { events = append(events, e) }}
+	interpreter.AddExecutionHook(hook)
+	for {
+		w, err := interpreter.Word()
+		if err != nil {
+			break
+		}
+		interpreter.Interpret(w)
+	}
+	interpreter.RemoveExecutionHook(hook)
+	interpreter.Interpret("1")
+
+	expected := []Event{
+		{Word: ":", Line: 1},
+		{Word: "3", Line: 3},
+		{Word: "square", Line: 3, Column: 2},
+		{Word: "dup", Depth: 1, Definition: "square", Line: 2, Column: 2},
+		{Word: "*", Depth: 1, Definition: "square", Line: 2, Column: 6},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, events)
+	}
+	for n := range expected {
+		if events[n] != expected[n] {
+			t.Errorf("expected %v, got %v", expected[n], events[n])
+		}
+	}
+}
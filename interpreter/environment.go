@@ -0,0 +1,204 @@
+package interpreter
+
+import (
+	"strings"
+)
+
+// environment is a source of words being interpreted, read a line at a time.
+// The parse area is the rest of the current line after position, which words
+// like parse and ." read from directly. It keeps track of the line the last
+// word was read from so definitions can record where they came from.
+type environment struct {
+	name  string
+	lines []string
+	n     int
+	first int
+	line  int
+
+	// start is where the last word read by Scan starts in the current line
+	start int
+	token string
+
+	// source is true for the input sources, the user input device, files
+	// and blocks, rather than the definitions being run. While a source is
+	// being interpreted its position is kept in data space at addr, so Forth
+	// code can read and change it with >in, otherwise it is kept in in.
+	source bool
+	in     int
+	memory *Memory
+	addr   int
+
+	// more reads another line onto the end of the text, for the user input
+	// device when a word continues past the end of the current line
+	more func() bool
+
+	// column is where the text starts in the first line of its source, for
+	// definitions that start part way through a line
+	column int
+}
+
+func newEnvironment(name string, source string, line int) *environment {
+	e := &environment{name: name, addr: -1}
+	e.reset(source, line)
+	return e
+}
+
+// reset replaces the text of the environment, starting at the given line.
+func (e *environment) reset(source string, line int) {
+	e.lines = strings.Split(source, "\n")
+	for n, l := range e.lines {
+		e.lines[n] = strings.TrimSuffix(l, "\r")
+	}
+	e.n = 0
+	e.first = line
+	e.line = line
+	e.setPosition(0)
+}
+
+// current returns the line being parsed.
+func (e *environment) current() string {
+	return e.lines[e.n]
+}
+
+// position returns the offset of the parse area in the current line.
+func (e *environment) position() int {
+	p := e.in
+	if e.addr >= 0 {
+		if v, err := e.memory.Fetch(e.addr); err == nil {
+			p = v
+		}
+	}
+	return max(0, min(p, len(e.current())))
+}
+
+func (e *environment) setPosition(p int) {
+	e.in = p
+	if e.addr >= 0 {
+		_ = e.memory.Store(e.addr, p)
+	}
+}
+
+// advance moves the parse area to the start of the next line, reading more
+// input if there isn't one and the environment can.
+func (e *environment) advance() bool {
+	return e.nextLine() || (e.more != nil && e.more())
+}
+
+// nextLine moves the parse area to the start of the next line, returning
+// false if there isn't one.
+func (e *environment) nextLine() bool {
+	if e.n+1 >= len(e.lines) {
+		e.setPosition(len(e.current()))
+		return false
+	}
+	e.n++
+	e.line = e.first + e.n
+	e.setPosition(0)
+	return true
+}
+
+// sourceColumn returns the offset in its source line of offset p in the
+// current line.
+func (e *environment) sourceColumn(p int) int {
+	if e.n == 0 {
+		return e.column + p
+	}
+	return p
+}
+
+func isSpace(c byte) bool {
+	return c <= ' '
+}
+
+// parseName skips leading spaces and returns the offsets of the following
+// word in the current line, they are equal at the end of the line.
+func (e *environment) parseName() (int, int) {
+	line := e.current()
+	start := e.position()
+	for start < len(line) && isSpace(line[start]) {
+		start++
+	}
+	end := start
+	for end < len(line) && !isSpace(line[end]) {
+		end++
+	}
+	e.setPosition(min(end+1, len(line)))
+	return start, end
+}
+
+// parse returns the offsets of the text up to the delimiter in the current
+// line, found is false if it runs to the end of the line instead.
+func (e *environment) parse(delimiter byte) (start int, end int, found bool) {
+	line := e.current()
+	start = e.position()
+	end = start
+	for end < len(line) && line[end] != delimiter {
+		end++
+	}
+	found = end < len(line)
+	e.setPosition(min(end+1, len(line)))
+	return start, end, found
+}
+
+// skipEscaped moves past the text up to the next quote in the current line,
+// skipping the character after each backslash.
+func (e *environment) skipEscaped() {
+	line := e.current()
+	p := e.position()
+	for p < len(line) && line[p] != '"' {
+		if line[p] == '\\' {
+			p++
+		}
+		p++
+	}
+	e.setPosition(min(p+1, len(line)))
+}
+
+// word is like parse, but skips any delimiters before the text first. Spaces
+// are treated as delimiters when the delimiter is a space.
+func (e *environment) word(delimiter byte) (int, int) {
+	if delimiter == ' ' {
+		return e.parseName()
+	}
+	line := e.current()
+	p := e.position()
+	for p < len(line) && line[p] == delimiter {
+		p++
+	}
+	e.setPosition(p)
+	start, end, _ := e.parse(delimiter)
+	return start, end
+}
+
+// Scan reads the next word, moving on to the following lines when the
+// current one runs out, and returns false at the end of the text.
+func (e *environment) Scan() bool {
+	for {
+		start, end := e.parseName()
+		if start < end {
+			e.start = start
+			e.token = e.current()[start:end]
+			e.line = e.first + e.n
+			return true
+		}
+		if !e.advance() {
+			return false
+		}
+	}
+}
+
+// Text returns the word read by the last call to Scan.
+func (e *environment) Text() string {
+	return e.token
+}
+
+// text returns the text from offset p1 of line n1 up to offset p2 of line n2.
+func (e *environment) text(n1 int, p1 int, n2 int, p2 int) string {
+	if n1 == n2 {
+		return e.lines[n1][p1:p2]
+	}
+	lines := []string{e.lines[n1][p1:]}
+	lines = append(lines, e.lines[n1+1:n2]...)
+	lines = append(lines, e.lines[n2][:p2])
+	return strings.Join(lines, "\n")
+}
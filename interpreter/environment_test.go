@@ -0,0 +1,36 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestEnvironmentLines(t *testing.T) {
+	e := newEnvironment("test.forth", "one two\n\nthree\n  four\nfive", 1)
+
+	expected := []struct {
+		word string
+		line int
+	}{
+		{"one", 1},
+		{"two", 1},
+		{"three", 3},
+		{"four", 4},
+		{"five", 5},
+	}
+
+	for _, x := range expected {
+		if !e.Scan() {
+			t.Fatalf("expected %s, got end of input", x.word)
+		}
+		if e.Text() != x.word {
+			t.Errorf("expected %s, got %s", x.word, e.Text())
+		}
+		if e.line != x.line {
+			t.Errorf("expected %s on line %d, got %d", x.word, x.line, e.line)
+		}
+	}
+
+	if e.Scan() {
+		t.Errorf("expected end of input, got %s", e.Text())
+	}
+}
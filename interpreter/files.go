@@ -0,0 +1,92 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+)
+
+// The ior values returned by the file words.
+const (
+	iorFileIO      = -37
+	iorNonExistent = -38
+)
+
+// ior converts an error to the ior returned by the file words, 0 for success.
+func ior(err error) int {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return iorNonExistent
+	}
+	return iorFileIO
+}
+
+// openFile opens the named file, returning its file id.
+func (i *Interpreter) openFile(name string, flag int) (int, error) {
+	f, err := os.OpenFile(name, flag, 0o666)
+	if err != nil {
+		return 0, err
+	}
+	i.nextFile++
+	i.files[i.nextFile] = f
+	return i.nextFile, nil
+}
+
+// popFile pops a file id off the stack, returning the open file it refers
+// to.
+func (i *Interpreter) popFile() (int, *os.File, error) {
+	id, err := i.stack.Top()
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.stack.Pop()
+	f, ok := i.files[id]
+	if !ok {
+		return id, nil, fmt.Errorf("invalid file id %d: %w", id, fs.ErrInvalid)
+	}
+	return id, f, nil
+}
+
+// readLine reads a line of up to n characters into memory at addr, leaving
+// the file positioned at the start of the next line. It returns the number of
+// characters read, and false at the end of the file.
+func (i *Interpreter) readLine(f *os.File, addr int, n int) (int, bool, error) {
+	position, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false, err
+	}
+
+	b := make([]byte, n+1)
+	read, err := f.Read(b)
+	if err == io.EOF {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	b = b[:read]
+
+	line := b
+	next := len(b)
+	if end := bytes.IndexByte(b, '\n'); end != -1 && end <= n {
+		line = b[:end]
+		next = end + 1
+	} else if len(b) > n {
+		line = b[:n]
+		next = n
+	}
+	line = bytes.TrimSuffix(line, []byte("\r"))
+
+	if err := i.memory.Write(addr, line); err != nil {
+		return 0, false, err
+	}
+	if _, err := f.Seek(position+int64(next), io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	return len(line), true, nil
+}
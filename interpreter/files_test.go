@@ -0,0 +1,109 @@
+package interpreter
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileAccess(t *testing.T) {
+	tests := map[string]struct {
+		files          map[string]string
+		input          string
+		expectedOutput string
+		expectedStack  []int
+		expectedFiles  map[string]string
+	}{
+		"create and write": {
+			input: "s\" out.txt\" w/o create-file swap constant fd " +
+				"s\" hello\" fd write-line s\" world\" fd write-file fd close-file",
+			expectedOutput: "",
+			expectedStack:  []int{0, 0, 0, 0},
+			expectedFiles:  map[string]string{"out.txt": "hello\nworld"},
+		},
+		"read lines": {
+			files: map[string]string{"in.txt": "one\r\ntwo\nthree"},
+			input: "create buf 80 allot s\" in.txt\" r/o open-file drop constant fd " +
+				"buf 80 fd read-line drop drop buf swap type " +
+				"buf 80 fd read-line drop drop buf swap type " +
+				"buf 80 fd read-line buf 5 type " +
+				"buf 80 fd read-line",
+			expectedOutput: "onetwothree",
+			expectedStack:  []int{5, -1, 0, 0, 0, 0},
+		},
+		"read a long line": {
+			files: map[string]string{"in.txt": "abcdef\n"},
+			input: "create buf 80 allot s\" in.txt\" r/o open-file drop constant fd " +
+				"buf 4 fd read-line drop drop buf swap type " +
+				"buf 4 fd read-line drop drop buf swap type",
+			expectedOutput: "abcdef",
+			expectedStack:  []int{},
+		},
+		"read file": {
+			files: map[string]string{"in.txt": "abcdef"},
+			input: "create buf 80 allot s\" in.txt\" r/o bin open-file drop constant fd " +
+				"buf 4 fd read-file drop buf swap type " +
+				"buf 4 fd read-file drop buf swap type " +
+				"buf 4 fd read-file",
+			expectedOutput: "abcdef",
+			expectedStack:  []int{0, 0},
+		},
+		"file size and position": {
+			files: map[string]string{"in.txt": "abcdef"},
+			input: "create buf 80 allot s\" in.txt\" r/w open-file drop constant fd " +
+				"fd file-size buf 2 fd read-file drop drop fd file-position " +
+				"1 0 fd reposition-file buf 1 fd read-file drop drop buf c@",
+			expectedOutput: "",
+			expectedStack:  []int{6, 0, 0, 2, 0, 0, 0, 98},
+		},
+		"open a missing file": {
+			input:          "s\" missing.txt\" r/o open-file",
+			expectedOutput: "",
+			expectedStack:  []int{0, -38},
+		},
+		"invalid file id": {
+			input:          "99 close-file",
+			expectedOutput: "",
+			expectedStack:  []int{-37},
+		},
+		"rename and delete": {
+			files:          map[string]string{"a.txt": "a", "b.txt": "b"},
+			input:          "s\" a.txt\" s\" c.txt\" rename-file s\" b.txt\" delete-file s\" b.txt\" delete-file",
+			expectedOutput: "",
+			expectedStack:  []int{0, 0, -38},
+			expectedFiles:  map[string]string{"c.txt": "a"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(writeFiles(t, test.files))
+
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+
+			for name, expected := range test.expectedFiles {
+				b, err := os.ReadFile(name)
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				if string(b) != expected {
+					t.Errorf("expected %s to contain %q, got %q", name, expected, b)
+				}
+			}
+		})
+	}
+}
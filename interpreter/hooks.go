@@ -0,0 +1,95 @@
+package interpreter
+
+import "slices"
+
+// Event describes a word run by the interpreter, for execution hooks.
+type Event struct {
+	// Word is the word as it was written
+	Word string
+	// Depth is the number of definitions being run
+	Depth int
+	// Definition is the name of the innermost definition being run, empty
+	// at the top level
+	Definition string
+	// File, Line and Column are where the word was written, File is empty
+	// for the user input device, Column counts from 0
+	File   string
+	Line   int
+	Column int
+}
+
+// ExecutionHook has functions called before and after each word is run.
+type ExecutionHook struct {
+	Before func(e Event)
+	After  func(e Event)
+}
+
+// AddExecutionHook adds a hook called as words are run.
+func (i *Interpreter) AddExecutionHook(hook *ExecutionHook) {
+	i.hooks = append(i.hooks, hook)
+}
+
+// RemoveExecutionHook removes a hook added by AddExecutionHook.
+func (i *Interpreter) RemoveExecutionHook(hook *ExecutionHook) {
+	i.hooks = slices.DeleteFunc(slices.Clone(i.hooks), func(h *ExecutionHook) bool {
+		return h == hook
+	})
+}
+
+// setHook adds the hook if on is true, moving it to the end of the hooks if
+// it has already been added, and otherwise removes it. The debugger, tracer,
+// profiler and coverage each have a hook that is only added while it's in
+// use, so words aren't slowed down by the ones that aren't.
+func (i *Interpreter) setHook(hook *ExecutionHook, on bool) {
+	i.RemoveExecutionHook(hook)
+	if on {
+		i.AddExecutionHook(hook)
+	}
+}
+
+// hooked returns true if the hook has been added.
+func (i *Interpreter) hooked(hook *ExecutionHook) bool {
+	return slices.Contains(i.hooks, hook)
+}
+
+// event describes the word about to be run.
+func (i *Interpreter) event(word string) Event {
+	top := i.environments[len(i.environments)-1]
+	e := Event{
+		Word:   word,
+		Depth:  len(i.frames.items),
+		Line:   top.line,
+		Column: top.sourceColumn(top.start),
+	}
+	if f, err := i.frames.Top(); err == nil {
+		e.Definition = f.word.name
+	}
+	e.File = i.currentFile()
+	return e
+}
+
+// Calls returns the names of the definitions being run, the innermost last.
+func (i *Interpreter) Calls() []string {
+	names := []string{}
+	for _, f := range i.frames.items {
+		names = append(names, f.word.name)
+	}
+	return names
+}
+
+// LoopStack returns a copy of the loop indexes, the innermost last.
+func (i *Interpreter) LoopStack() []int {
+	return append([]int{}, i.loopStack.items...)
+}
+
+// eventWord returns the dictionary word an event runs, false for numbers and
+// locals. It must be called before the word is run, while the definition it
+// was written in is still the one being run.
+func (i *Interpreter) eventWord(e Event) (*ExecutableToken, bool) {
+	if f, err := i.frames.Top(); err == nil {
+		if _, ok := f.locals[e.Word]; ok {
+			return nil, false
+		}
+	}
+	return i.resolve(e.Word)
+}
@@ -0,0 +1,167 @@
+package interpreter
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// library holds the standard library, files in it are found after those on
+// disk, i.e. require std/strings.
+//
+//go:embed lib
+var library embed.FS
+
+// extensions are tried in turn when looking for a file to include, so the
+// extension can be left off.
+var extensions = []string{"", ".forth", ".fs"}
+
+// inclusion is a file that can be included, name is the path it was found at
+// and abs identifies it, it is the absolute path of files on disk.
+type inclusion struct {
+	name     string
+	abs      string
+	embedded bool
+}
+
+func (f inclusion) read() ([]byte, error) {
+	if f.embedded {
+		return library.ReadFile(path.Join("lib", f.name))
+	}
+	return os.ReadFile(f.name)
+}
+
+// Include interprets the named file, it is found in the same way as the
+// include word finds files.
+func (i *Interpreter) Include(name string) error {
+	file, err := i.findFile(name)
+	if err != nil {
+		return err
+	}
+	return i.includeFile(file)
+}
+
+// Require interprets the named file unless it has already been included.
+func (i *Interpreter) Require(name string) error {
+	file, err := i.findFile(name)
+	if err != nil {
+		return err
+	}
+	if i.included[file.abs] {
+		return nil
+	}
+	return i.includeFile(file)
+}
+
+// includeFile interprets the file.
+func (i *Interpreter) includeFile(file inclusion) error {
+	for n, e := range i.including {
+		if e.abs == file.abs {
+			var cycle []string
+			for _, e := range i.including[n:] {
+				cycle = append(cycle, e.name)
+			}
+			cycle = append(cycle, file.name)
+			return fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	source, err := file.read()
+	if err != nil {
+		return err
+	}
+	i.included[file.abs] = true
+	i.coverFile(file.name, string(source))
+
+	i.including = append(i.including, file)
+	i.pushSource(newEnvironment(file.name, string(source), 1))
+	for !i.bye && i.environments[len(i.environments)-1].Scan() {
+		t := i.environments[len(i.environments)-1].Text()
+		i.Interpret(t)
+	}
+	i.popSource()
+	i.including = i.including[:len(i.including)-1]
+	if i.bye {
+		return ErrBye
+	}
+	return nil
+}
+
+// findFile finds the named file, looking in the directory of the file being
+// interpreted, the working directory, the directories listed in FORTHPATH and
+// then the standard library.
+func (i *Interpreter) findFile(name string) (inclusion, error) {
+	var dirs []string
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	} else {
+		if file := i.sourceName(); file != "" {
+			dirs = append(dirs, filepath.Dir(file))
+		}
+		dirs = append(dirs, ".")
+		dirs = append(dirs, filepath.SplitList(os.Getenv("FORTHPATH"))...)
+	}
+
+	for _, dir := range dirs {
+		for _, extension := range extensions {
+			name := filepath.Join(dir, name+extension)
+			if info, err := os.Stat(name); err == nil && !info.IsDir() {
+				abs, err := filepath.Abs(name)
+				if err != nil {
+					return inclusion{}, err
+				}
+				return inclusion{name: name, abs: abs}, nil
+			}
+		}
+	}
+
+	for _, extension := range extensions {
+		name := path.Clean(filepath.ToSlash(name + extension))
+		if info, err := fs.Stat(library, path.Join("lib", name)); err == nil && !info.IsDir() {
+			return inclusion{name: name, abs: "library:" + name, embedded: true}, nil
+		}
+	}
+
+	return inclusion{}, fmt.Errorf("cannot find file %s", name)
+}
+
+// sourceName returns the name of the file being interpreted, or an empty
+// string if the input isn't from a file.
+func (i *Interpreter) sourceName() string {
+	if e := i.sourceEnvironment(); e != nil {
+		return e.name
+	}
+	return ""
+}
+
+// currentFile returns the name of the file the word being run was written
+// in, the file of the definition being run or else the file being
+// interpreted.
+func (i *Interpreter) currentFile() string {
+	if f, err := i.frames.Top(); err == nil {
+		return f.word.file
+	}
+	return i.sourceName()
+}
+
+// location returns where in the file being interpreted the last word was
+// read from, for prefixing error messages.
+func (i *Interpreter) location() string {
+	if e := i.sourceEnvironment(); e != nil {
+		return fmt.Sprintf("%s:%d: ", e.name, e.line)
+	}
+	return ""
+}
+
+func (i *Interpreter) sourceEnvironment() *environment {
+	for n := len(i.environments) - 1; n >= 0; n-- {
+		if i.environments[n].name != "" {
+			return i.environments[n]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInclude(t *testing.T) {
+	tests := map[string]struct {
+		files          map[string]string
+		forthpath      string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"include": {
+			files: map[string]string{
+				"main.forth": "include lib.forth 2 double",
+				"lib.forth":  ": double 2 * ;",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{4},
+		},
+		"include without the extension": {
+			files: map[string]string{
+				"main.forth": "include lib 2 double",
+				"lib.forth":  ": double 2 * ;",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{4},
+		},
+		"include relative to the current file": {
+			files: map[string]string{
+				"main.forth":      "include lib/outer.forth 1 inner outer",
+				"lib/outer.forth": "include inner.forth : outer 2 ;",
+				"lib/inner.forth": ": inner 3 ;",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{1, 3, 2},
+		},
+		"included": {
+			files: map[string]string{
+				"main.forth": "s\" lib.forth\" included 2 double",
+				"lib.forth":  ": double 2 * ;",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{4},
+		},
+		"include twice": {
+			files: map[string]string{
+				"main.forth": "include lib.forth include lib.forth",
+				"lib.forth":  "1",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{1, 1},
+		},
+		"require once": {
+			files: map[string]string{
+				"main.forth": "require lib.forth require lib s\" lib.forth\" required",
+				"lib.forth":  "1",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{1},
+		},
+		"require after include": {
+			files: map[string]string{
+				"main.forth": "include lib.forth require lib.forth",
+				"lib.forth":  "1",
+			},
+			expectedOutput: "",
+			expectedStack:  []int{1},
+		},
+		"search path": {
+			files: map[string]string{
+				"main.forth":          "include lib.forth 2 double",
+				"libraries/lib.forth": ": double 2 * ;",
+			},
+			forthpath:      "libraries",
+			expectedOutput: "",
+			expectedStack:  []int{4},
+		},
+		"missing file": {
+			files: map[string]string{
+				"main.forth": "include missing.forth 1",
+			},
+			expectedOutput: "main.forth:1: cannot find file missing.forth\n",
+			expectedStack:  []int{1},
+		},
+		"cycle": {
+			files: map[string]string{
+				"main.forth": "include a.forth",
+				"a.forth":    "1 include b.forth",
+				"b.forth":    "2 include a.forth",
+			},
+			expectedOutput: "b.forth:1: include cycle: a.forth -> b.forth -> a.forth\n",
+			expectedStack:  []int{1, 2},
+		},
+		"error location": {
+			files: map[string]string{
+				"main.forth": "include lib.forth",
+				"lib.forth":  "1\n2\nnothing",
+			},
+			expectedOutput: "lib.forth:3: nothing ?\n",
+			expectedStack:  []int{1, 2},
+		},
+		"error location in a definition": {
+			files: map[string]string{
+				"main.forth": "include lib.forth\nbroken",
+				"lib.forth":  ": broken\n  nothing ;",
+			},
+			expectedOutput: "main.forth:2: nothing ?\n",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := writeFiles(t, test.files)
+			t.Chdir(dir)
+			t.Setenv("FORTHPATH", test.forthpath)
+
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, "")
+			if err := interpreter.Include("main.forth"); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestIncludeMissingFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, "")
+	if err := interpreter.Include("missing.forth"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestByeEndsInclude(t *testing.T) {
+	t.Chdir(writeFiles(t, map[string]string{
+		"main.forth": "1 . include lib.forth 3 .",
+		"lib.forth":  "2 . bye 4 .",
+	}))
+
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, "")
+	if err := interpreter.Include("main.forth"); !errors.Is(err, ErrBye) {
+		t.Errorf("expected ErrBye, got %v", err)
+	}
+	if expected := "1 2 "; expected != o.String() {
+		t.Errorf("expected '%v', got '%v'", expected, o.String())
+	}
+	if _, err := interpreter.Word(); !errors.Is(err, ErrBye) {
+		t.Errorf("expected ErrBye, got %v", err)
+	}
+}
+
+func TestStandardLibrary(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		files          map[string]string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"stack": {
+			input:          "require std/stack 1 2 nip 3 4 tuck 5 6 2drop 0 ?dup",
+			expectedOutput: "",
+			expectedStack:  []int{2, 4, 3, 4, 0},
+		},
+		"2swap and 2over": {
+			input:          "require std/stack 1 2 3 4 2swap 2over",
+			expectedOutput: "",
+			expectedStack:  []int{3, 4, 1, 2, 3, 4},
+		},
+		"math": {
+			input:          "require std/math 12 18 gcd 4 6 lcm 2 10 pow 17 sqrt -5 abs 3 7 min 3 7 max",
+			expectedOutput: "",
+			expectedStack:  []int{6, 12, 1024, 4, 5, 3, 7},
+		},
+		"strings": {
+			input:          "require std/strings s\" abc\" s\" abc\" str= s\" abc\" s\" abd\" str= s\" ab  \" -trailing swap drop",
+			expectedOutput: "",
+			expectedStack:  []int{-1, 0, 2},
+		},
+		"upcase!": {
+			input:          "require std/strings s\" Hello\" 2dup upcase! type",
+			expectedOutput: "HELLO",
+			expectedStack:  []int{},
+		},
+		"assertions": {
+			input:          "require std/assert 1 1 assert= 1 2 assert= -1 assert 0 assert",
+			expectedOutput: "assertion failed: expected 2 got 1 \nassertion failed\n",
+			expectedStack:  []int{},
+		},
+		"everything": {
+			input:          "require std 10 4 gcd 3 4 nip",
+			expectedOutput: "",
+			expectedStack:  []int{2, 4},
+		},
+		"files on disk come first": {
+			input:          "require std/math 1 negate",
+			files:          map[string]string{"std/math.forth": ": negate drop 99 ;"},
+			expectedOutput: "",
+			expectedStack:  []int{99},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			files := map[string]string{"main.forth": test.input}
+			for name, source := range test.files {
+				files[name] = source
+			}
+			t.Chdir(writeFiles(t, files))
+
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, "")
+			if err := interpreter.Include("main.forth"); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package interpreter
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// Option configures an Interpreter created by NewInterpreter.
+type Option func(*Interpreter)
+
+// WithInput sets the reader the input words key, accept and refill read from,
+// by default they read from stdin. A bufio.Reader is used as it is, so the
+// input can be shared with other readers of it.
+func WithInput(r io.Reader) Option {
+	return func(i *Interpreter) {
+		if b, ok := r.(*bufio.Reader); ok {
+			i.input = b
+		} else {
+			i.input = bufio.NewReader(r)
+		}
+		_, i.blocking = r.(*os.File)
+	}
+}
+
+// WithErrorOutput sets the writer errors and warnings are written to, by
+// default they are written to the interpreter's output.
+func WithErrorOutput(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.errOut = w
+	}
+}
+
+// WithLineReader sets the function the REPL's lines are read with, by
+// default they are read from the input. Definitions and comments left
+// unfinished at the end of a line then continue on the lines read next.
+func WithLineReader(read func() (string, error)) Option {
+	return func(i *Interpreter) {
+		i.lineReader = read
+	}
+}
+
+// Refill reads the next line of input and makes it the line being
+// interpreted, replacing what is left of the current one.
+func (i *Interpreter) Refill() error {
+	line, err := i.readUserLine()
+	if err != nil {
+		return err
+	}
+	e := i.environments[0]
+	e.reset(line, e.line+1)
+	return nil
+}
+
+// WithContinuationPrompt sets a function called to show a prompt before
+// reading another line of input for a definition or comment that continues
+// past the end of a line. Without it or a line reader nothing continues past
+// the end of the source.
+func WithContinuationPrompt(prompt func()) Option {
+	return func(i *Interpreter) {
+		i.continuationPrompt = prompt
+	}
+}
+
+// continueInput reads the next line of input onto the end of e, the user
+// input device, while a word is reading text past the end of the current
+// line. It returns false at the end of the input, abandoning the word.
+func (i *Interpreter) continueInput(e *environment) bool {
+	if i.running.Load() == 0 {
+		return false
+	}
+	if i.continuationPrompt != nil {
+		i.continuationPrompt()
+	}
+	line, err := i.readUserLine()
+	if err != nil {
+		return false
+	}
+	e.lines = append(e.lines, line)
+	return e.nextLine()
+}
+
+// readUserLine reads a line for the user input device.
+func (i *Interpreter) readUserLine() (string, error) {
+	if i.lineReader != nil {
+		return i.lineReader()
+	}
+	return i.readInput()
+}
+
+// readInput reads a line from the input without its line terminator, only
+// returning an error if there is nothing left to read.
+func (i *Interpreter) readInput() (string, error) {
+	line, err := i.input.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// keyAvailable returns true if a character can be read from the input without
+// waiting. Input from a file or terminal may block, so only characters
+// already buffered count.
+func (i *Interpreter) keyAvailable() bool {
+	if i.input.Buffered() > 0 {
+		return true
+	}
+	if i.blocking {
+		return false
+	}
+	_, err := i.input.Peek(1)
+	return err == nil
+}
@@ -0,0 +1,173 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInput(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		keyboard       string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"key": {
+			input:          "key key key",
+			keyboard:       "ab",
+			expectedOutput: "",
+			expectedStack:  []int{97, 98, -1},
+		},
+		"key?": {
+			input:          "key? key drop key?",
+			keyboard:       "a",
+			expectedOutput: "",
+			expectedStack:  []int{-1, 0},
+		},
+		"ekey": {
+			input:          "ekey ekey",
+			keyboard:       "éa",
+			expectedOutput: "",
+			expectedStack:  []int{233, 97},
+		},
+		"accept": {
+			input:          "create buf 80 allot buf 80 accept buf swap type buf 3 accept buf swap type buf 80 accept",
+			keyboard:       "hello\r\nworld\n",
+			expectedOutput: "hellowor",
+			expectedStack:  []int{0},
+		},
+		"refill": {
+			input:          "refill 1 2",
+			keyboard:       "3 4\n",
+			expectedOutput: "",
+			expectedStack:  []int{-1, 3, 4},
+		},
+		"refill at the end of input": {
+			input:          "refill",
+			keyboard:       "",
+			expectedOutput: "",
+			expectedStack:  []int{0},
+		},
+		"filter": {
+			input: ": upper key dup -1 = if drop else " +
+				"dup 96 > over 123 < and if 32 - then emit recurse then ; upper",
+			keyboard:       "Hello!",
+			expectedOutput: "HELLO!",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input, WithInput(strings.NewReader(test.keyboard)))
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestContinuedLines(t *testing.T) {
+	tests := map[string]struct {
+		input           string
+		keyboard        string
+		expectedOutput  string
+		expectedStack   []int
+		expectedPrompts int
+	}{
+		"definition": {
+			input:           ": add",
+			keyboard:        "+\n;\n1 2 add\n",
+			expectedOutput:  "",
+			expectedStack:   []int{3},
+			expectedPrompts: 2,
+		},
+		"if": {
+			input:           "-1 if",
+			keyboard:        "1\nelse 2 then 3\n",
+			expectedOutput:  "",
+			expectedStack:   []int{1, 3},
+			expectedPrompts: 2,
+		},
+		"do": {
+			input:           "3 0 do",
+			keyboard:        "i loop\n",
+			expectedOutput:  "",
+			expectedStack:   []int{0, 1, 2},
+			expectedPrompts: 1,
+		},
+		"end of input abandons the definition": {
+			input:           ": half 1",
+			keyboard:        "2",
+			expectedOutput:  "missing ';' in the definition of half\n",
+			expectedStack:   []int{},
+			expectedPrompts: 2,
+		},
+		"complete lines don't prompt": {
+			input:           ": one 1 ; one",
+			keyboard:        "2\n",
+			expectedOutput:  "",
+			expectedStack:   []int{1, 2},
+			expectedPrompts: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			prompts := 0
+			interpreter := NewInterpreter(&o, test.input,
+				WithInput(strings.NewReader(test.keyboard)),
+				WithContinuationPrompt(func() { prompts++ }))
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					if interpreter.Refill() != nil {
+						break
+					}
+					continue
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+			if test.expectedPrompts != prompts {
+				t.Errorf("expected %d prompts, got %d", test.expectedPrompts, prompts)
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestNoContinuationWithoutPrompt(t *testing.T) {
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, ": half 1", WithInput(strings.NewReader("2 ;\n")))
+	for {
+		w, err := interpreter.Word()
+		if err != nil {
+			break
+		}
+		interpreter.Interpret(w)
+	}
+
+	if expected := "missing ';' in the definition of half\n"; expected != o.String() {
+		t.Errorf("expected '%v', got '%v'", expected, o.String())
+	}
+	if line, err := interpreter.readInput(); err != nil || line != "2 ;" {
+		t.Errorf("expected the input to be left unread, got '%v' %v", line, err)
+	}
+}
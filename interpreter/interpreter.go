@@ -7,43 +7,135 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 type ExecutableToken struct {
 	name      string
 	primitive func()
+	xt        int
+
+	// effect is the stack effect comment, i.e. ( n1 n2 -- sum )
+	effect    string
+	immediate bool
+
+	// definition is the source of a colon definition, file and line are
+	// where it was defined and first and column where its source starts
+	colon      bool
+	definition string
+	file       string
+	line       int
+	first      int
+	column     int
+
+	// here is the next free address of data space when the word was defined
+	here int
+
+	// body is the data field address of a created word
+	body    int
+	created bool
+
+	// action is the word a deferred word executes
+	action   *ExecutableToken
+	deferred bool
+}
+
+// frame holds the state of a single invocation of a colon definition, giving
+// each call its own set of locals. bindings are the words the definition's
+// names referred to when it was defined, so redefining a word later doesn't
+// change what existing definitions call.
+type frame struct {
+	word     *ExecutableToken
+	locals   map[string]int
+	bindings map[string]*ExecutableToken
 }
 
 type Interpreter struct {
-	environments []*bufio.Scanner
+	environments []*environment
 	out          io.Writer
+	errOut       io.Writer
 	stack        Stack[int]
 	loopStack    Stack[int]
-	dictionary   map[string]ExecutableToken
+	frames       Stack[*frame]
+	structures   Stack[*int]
+	memory       Memory
+	wordlists    []*Wordlist
+	order        []int
+	current      int
+	xts          []*ExecutableToken
+	builtins     int
+	protect      bool
+	including    []inclusion
+	files        map[int]*os.File
+	nextFile     int
+	blocks       *blocks
+	input        *bufio.Reader
+	toIn         int
+	inputBuffer  int
+	wordBuffer   int
+
+	// bye is set once bye has been run
+	bye bool
+
+	// running counts the words being run, lines are only read for words
+	// that continue onto them
+	running            atomic.Int32
+	interrupted        atomic.Bool
+	continuationPrompt func()
+	lineReader         func() (string, error)
+	hooks              []*ExecutionHook
+	debugger           *debugger
+	tracer             *tracer
+	profiler           *profiler
+	coverage           *coverage
+	blocking           bool
+	included           map[string]bool
+	quotations         map[site]*ExecutableToken
+	literals           map[site]int
 }
 
-func NewInterpreter(writer io.Writer, source string) *Interpreter {
+func NewInterpreter(writer io.Writer, source string, options ...Option) *Interpreter {
 	i := Interpreter{
 		out:        writer,
+		errOut:     writer,
 		stack:      Stack[int]{},
-		dictionary: make(map[string]ExecutableToken),
+		wordlists:  []*Wordlist{NewWordlist("forth")},
+		order:      minimumOrder(),
+		quotations: make(map[site]*ExecutableToken),
+		literals:   make(map[site]int),
+		included:   make(map[string]bool),
+		files:      make(map[int]*os.File),
+	}
+	i.reserveInputBuffers()
+	i.pushSource(newEnvironment("", source, 1))
+	WithInput(os.Stdin)(&i)
+	for _, option := range options {
+		option(&i)
+	}
+	// only a REPL reads the rest of a definition from the lines that follow,
+	// the source given to an embedded interpreter is all there is
+	if i.lineReader != nil || i.continuationPrompt != nil {
+		i.environments[0].more = func() bool {
+			return i.continueInput(i.environments[0])
+		}
 	}
-	i.environments = append(i.environments, bufio.NewScanner(strings.NewReader(source)))
-	i.environments[0].Split(bufio.ScanWords)
 
 	// Quiting
-	i.dictionary["bye"] = ExecutableToken{
-		name: "bye",
+	i.define(&ExecutableToken{
+		name:   "bye",
+		effect: "( -- )",
 		primitive: func() {
-			os.Exit(0)
+			panic(errBye)
 		},
-	}
+	})
 
 	// Mathematical Operations
-	i.dictionary["+"] = ExecutableToken{
-		name: "+",
+	i.define(&ExecutableToken{
+		name:   "+",
+		effect: "( n1 n2 -- sum )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -57,9 +149,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Pop()
 			i.stack.Push(a + b)
 		},
-	}
-	i.dictionary["-"] = ExecutableToken{
-		name: "-",
+	})
+	i.define(&ExecutableToken{
+		name:   "-",
+		effect: "( n1 n2 -- diff )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -73,9 +166,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Pop()
 			i.stack.Push(b - a)
 		},
-	}
-	i.dictionary["*"] = ExecutableToken{
-		name: "*",
+	})
+	i.define(&ExecutableToken{
+		name:   "*",
+		effect: "( n1 n2 -- product )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -89,9 +183,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Pop()
 			i.stack.Push(a * b)
 		},
-	}
-	i.dictionary["/"] = ExecutableToken{
-		name: "/",
+	})
+	i.define(&ExecutableToken{
+		name:   "/",
+		effect: "( n1 n2 -- quotient )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -105,9 +200,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Pop()
 			i.stack.Push(b / a)
 		},
-	}
-	i.dictionary["mod"] = ExecutableToken{
-		name: "mod",
+	})
+	i.define(&ExecutableToken{
+		name:   "mod",
+		effect: "( n1 n2 -- remainder )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -121,11 +217,12 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Pop()
 			i.stack.Push(b % a)
 		},
-	}
+	})
 
 	// Stack manipulation
-	i.dictionary["swap"] = ExecutableToken{
-		name: "swap",
+	i.define(&ExecutableToken{
+		name:   "swap",
+		effect: "( n1 n2 -- n2 n1 )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -140,9 +237,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Push(a)
 			i.stack.Push(b)
 		},
-	}
-	i.dictionary["dup"] = ExecutableToken{
-		name: "dup",
+	})
+	i.define(&ExecutableToken{
+		name:   "dup",
+		effect: "( n -- n n )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -150,9 +248,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			}
 			i.stack.Push(a)
 		},
-	}
-	i.dictionary["over"] = ExecutableToken{
-		name: "over",
+	})
+	i.define(&ExecutableToken{
+		name:   "over",
+		effect: "( n1 n2 -- n1 n2 n1 )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -166,9 +265,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Push(a)
 			i.stack.Push(b)
 		},
-	}
-	i.dictionary["rot"] = ExecutableToken{
-		name: "rot",
+	})
+	i.define(&ExecutableToken{
+		name:   "rot",
+		effect: "( n1 n2 n3 -- n2 n3 n1 )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -189,17 +289,19 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 			i.stack.Push(a)
 			i.stack.Push(c)
 		},
-	}
-	i.dictionary["drop"] = ExecutableToken{
-		name: "drop",
+	})
+	i.define(&ExecutableToken{
+		name:   "drop",
+		effect: "( n -- )",
 		primitive: func() {
 			i.stack.Pop()
 		},
-	}
+	})
 
 	// Output
-	i.dictionary["."] = ExecutableToken{
-		name: ".",
+	i.define(&ExecutableToken{
+		name:   ".",
+		effect: "( n -- )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -211,9 +313,10 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 				log.Fatal(err)
 			}
 		},
-	}
-	i.dictionary["emit"] = ExecutableToken{
-		name: "emit",
+	})
+	i.define(&ExecutableToken{
+		name:   "emit",
+		effect: "( char -- )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
@@ -225,46 +328,31 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 				log.Fatal(err)
 			}
 		},
-	}
-	i.dictionary["cr"] = ExecutableToken{
-		name: "cr",
+	})
+	i.define(&ExecutableToken{
+		name:   "cr",
+		effect: "( -- )",
 		primitive: func() {
 			_, err := fmt.Fprintln(i.out)
 			if err != nil {
 				log.Fatal(err)
 			}
 		},
-	}
-	i.dictionary[".\""] = ExecutableToken{
-		name: ".\"",
+	})
+	i.define(&ExecutableToken{
+		name:      ".\"",
+		effect:    "( -- )",
+		immediate: true,
 		primitive: func() {
-			first := true
-			for i.environments[len(i.environments)-1].Scan() {
-				if !first {
-					_, err := fmt.Fprint(i.out, " ")
-					if err != nil {
-						log.Fatal(err)
-					}
-				}
-				w := i.environments[len(i.environments)-1].Text()
-				if w[len(w)-1:] == "\"" {
-					w = w[:len(w)-1]
-					_, err := fmt.Fprint(i.out, w)
-					if err != nil {
-						log.Fatal(err)
-					}
-					break
-				}
-				_, err := fmt.Fprint(i.out, w)
-				if err != nil {
-					log.Fatal(err)
-				}
-				first = false
+			_, err := fmt.Fprint(i.out, i.parseString())
+			if err != nil {
+				log.Fatal(err)
 			}
 		},
-	}
-	i.dictionary[".S"] = ExecutableToken{
-		name: ".S",
+	})
+	i.define(&ExecutableToken{
+		name:   ".S",
+		effect: "( -- )",
 		primitive: func() {
 			_, err := fmt.Fprintf(i.out, "<%d> ", len(i.stack.items))
 			if err != nil {
@@ -278,343 +366,2433 @@ func NewInterpreter(writer io.Writer, source string) *Interpreter {
 				}
 			}
 		},
-	}
+	})
 
 	// Defining words
-	i.dictionary[":"] = ExecutableToken{
-		name: ":",
+	i.define(&ExecutableToken{
+		name:   ":",
+		effect: "( -- )",
 		primitive: func() {
 			name, err := i.Word()
 			if err != nil {
 				log.Fatal(err)
 			}
-			words := []string{}
-			for i.environments[len(i.environments)-1].Scan() {
-				t := i.environments[len(i.environments)-1].Text()
-				if t != ";" {
-					words = append(words, t)
-				} else {
-					break
-				}
+			e := i.environments[len(i.environments)-1]
+			file, line, column := e.name, e.line, e.sourceColumn(e.position())
+			definition, closed := i.collect(func(w string) bool { return w == ";" })
+			if !closed {
+				panic(fmt.Sprintf("missing ';' in the definition of %s\n", name))
 			}
-			definition := strings.Join(words, " ")
-			xt := ExecutableToken{
-				name: name,
-				primitive: func() {
-					s := bufio.NewScanner(strings.NewReader(definition))
-					s.Split(bufio.ScanWords)
-					i.environments = append(i.environments, s)
-					for i.environments[len(i.environments)-1].Scan() {
-						t := i.environments[len(i.environments)-1].Text()
-						i.Interpret(t)
+			word := i.colonDefinition(name, strings.TrimSpace(definition))
+			word.file = file
+			word.line = line
+			word.first, word.column = sourceStart(definition, line, column)
+			i.define(word)
+		},
+	})
+
+	i.define(&ExecutableToken{
+		name:      "recurse",
+		effect:    "( -- )",
+		immediate: true,
+		primitive: func() {
+			f, err := i.frames.Top()
+			if err != nil {
+				panic("recurse can only be used inside a definition")
+			}
+			f.word.primitive()
+		},
+	})
+
+	// Quotations
+	i.define(&ExecutableToken{
+		name:      "[:",
+		effect:    "( -- xt )",
+		immediate: true,
+		primitive: func() {
+			e := i.environments[len(i.environments)-1]
+			line, column := e.line, e.sourceColumn(e.position())
+			here := i.site()
+			depth := 0
+			definition, closed := i.collect(func(w string) bool {
+				if w == "[:" {
+					depth++
+				} else if w == ";]" {
+					if depth == 0 {
+						return true
 					}
-					i.environments = i.environments[:len(i.environments)-1]
-				},
+					depth--
+				}
+				return false
+			})
+			if !closed {
+				panic("missing ';]'")
 			}
-			i.dictionary[name] = xt
+			first, column := sourceStart(definition, line, column)
+			definition = strings.TrimSpace(definition)
+
+			// a quotation inside a definition is read each time the
+			// definition runs, so reuse the word made the first time
+			quotation, ok := i.quotations[here]
+			if !ok {
+				quotation = i.colonDefinition("[: "+definition+" ;]", definition)
+				quotation.file = i.currentFile()
+				quotation.first = first
+				quotation.column = column
+				i.register(quotation)
+				i.quotations[here] = quotation
+			}
+			i.stack.Push(quotation.xt)
 		},
-	}
+	})
 
-	// Comments
-	i.dictionary["("] = ExecutableToken{
-		name: "(",
+	// Locals
+	i.define(&ExecutableToken{
+		name:      "{:",
+		effect:    "( x1 .. xn -- )",
+		immediate: true,
 		primitive: func() {
-			// Consume the text until the end of comment ')'
+			// {: args | values -- outputs :}
+			var args, values []string
+			uninitialized := false
+			comment := false
+			closed := false
 			for i.environments[len(i.environments)-1].Scan() {
-				t := i.environments[len(i.environments)-1].Text()
-				if t == ")" {
+				w := i.environments[len(i.environments)-1].Text()
+				if w == ":}" {
+					closed = true
 					break
+				} else if comment {
+					continue
+				} else if w == "--" {
+					comment = true
+				} else if w == "|" {
+					uninitialized = true
+				} else if uninitialized {
+					values = append(values, w)
+				} else {
+					args = append(args, w)
 				}
 			}
-		},
-	}
+			if !closed {
+				panic("missing ':}'")
+			}
+			f, err := i.frames.Top()
+			if err != nil {
+				panic("locals can only be declared inside a definition")
+			}
 
-	// Comparrisions
-	i.dictionary["="] = ExecutableToken{
-		name: "=",
+			// the last argument is initialised from the top of the stack
+			for n := len(args) - 1; n >= 0; n-- {
+				v, err := i.stack.Top()
+				if err != nil {
+					log.Fatal(err)
+				}
+				i.stack.Pop()
+				f.locals[args[n]] = v
+			}
+			for _, name := range values {
+				f.locals[name] = 0
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "to",
+		effect:    "( n -- )",
+		immediate: true,
 		primitive: func() {
-			a, err := i.stack.Top()
+			name, err := i.Word()
 			if err != nil {
 				log.Fatal(err)
 			}
-			i.stack.Pop()
-			b, err := i.stack.Top()
+			f, err := i.frames.Top()
 			if err != nil {
-				log.Fatal(err)
+				panic(fmt.Sprintf("%s ?\n", name))
 			}
-			i.stack.Pop()
-			if a == b {
-				i.stack.Push(-1)
-			} else {
-				i.stack.Push(0)
+			if _, ok := f.locals[name]; !ok {
+				panic(fmt.Sprintf("%s ?\n", name))
 			}
-		},
-	}
-	i.dictionary["<"] = ExecutableToken{
-		name: "<",
-		primitive: func() {
-			a, err := i.stack.Top()
+			v, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			b, err := i.stack.Top()
+			f.locals[name] = v
+		},
+	})
+
+	// Data space
+	i.define(&ExecutableToken{
+		name:   "here",
+		effect: "( -- addr )",
+		primitive: func() {
+			i.stack.Push(i.memory.Here())
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "allot",
+		effect: "( n -- )",
+		primitive: func() {
+			n, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if b < a {
-				i.stack.Push(-1)
-			} else {
-				i.stack.Push(0)
+			if err := i.memory.Allot(n); err != nil {
+				panic(err.Error())
 			}
 		},
-	}
-	i.dictionary[">"] = ExecutableToken{
-		name: ">",
+	})
+	i.define(&ExecutableToken{
+		name:   "align",
+		effect: "( -- )",
+		primitive: func() {
+			i.memory.Align()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "aligned",
+		effect: "( addr -- a-addr )",
 		primitive: func() {
 			a, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			b, err := i.stack.Top()
+			i.stack.Push(Aligned(a))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   ",",
+		effect: "( n -- )",
+		primitive: func() {
+			v, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if b > a {
-				i.stack.Push(-1)
-			} else {
-				i.stack.Push(0)
+			addr := i.memory.Here()
+			_ = i.memory.Allot(CellSize)
+			if err := i.memory.Store(addr, v); err != nil {
+				panic(err.Error())
 			}
 		},
-	}
-	i.dictionary["<>"] = ExecutableToken{
-		name: "<>",
+	})
+	i.define(&ExecutableToken{
+		name:   "c,",
+		effect: "( char -- )",
 		primitive: func() {
-			a, err := i.stack.Top()
+			v, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			b, err := i.stack.Top()
+			addr := i.memory.Here()
+			_ = i.memory.Allot(1)
+			if err := i.memory.StoreByte(addr, v); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "@",
+		effect: "( addr -- n )",
+		primitive: func() {
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if b != a {
-				i.stack.Push(-1)
-			} else {
-				i.stack.Push(0)
+			v, err := i.memory.Fetch(addr)
+			if err != nil {
+				panic(err.Error())
 			}
+			i.stack.Push(v)
 		},
-	}
-
-	// Boolean Operators
-	i.dictionary["and"] = ExecutableToken{
-		name: "and",
+	})
+	i.define(&ExecutableToken{
+		name:   "!",
+		effect: "( n addr -- )",
 		primitive: func() {
-			a, err := i.stack.Top()
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			b, err := i.stack.Top()
+			v, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if b == -1 && a == -1 {
-				i.stack.Push(-1)
-			} else {
-				i.stack.Push(0)
+			if err := i.memory.Store(addr, v); err != nil {
+				panic(err.Error())
 			}
 		},
-	}
-	i.dictionary["or"] = ExecutableToken{
-		name: "or",
+	})
+	i.define(&ExecutableToken{
+		name:   "+!",
+		effect: "( n addr -- )",
 		primitive: func() {
-			a, err := i.stack.Top()
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			b, err := i.stack.Top()
+			n, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if b == -1 || a == -1 {
-				i.stack.Push(-1)
-			} else {
-				i.stack.Push(0)
+			v, err := i.memory.Fetch(addr)
+			if err != nil {
+				panic(err.Error())
+			}
+			if err := i.memory.Store(addr, v+n); err != nil {
+				panic(err.Error())
 			}
 		},
-	}
-	i.dictionary["invert"] = ExecutableToken{
-		name: "invert",
+	})
+	i.define(&ExecutableToken{
+		name:   "c@",
+		effect: "( addr -- char )",
 		primitive: func() {
-			a, err := i.stack.Top()
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if a == -1 {
-				i.stack.Push(0)
-			} else {
-				i.stack.Push(-1)
+			v, err := i.memory.FetchByte(addr)
+			if err != nil {
+				panic(err.Error())
 			}
+			i.stack.Push(v)
 		},
-	}
-
-	// if
-	i.dictionary["if"] = ExecutableToken{
-		name: "if",
+	})
+	i.define(&ExecutableToken{
+		name:   "c!",
+		effect: "( char addr -- )",
 		primitive: func() {
-			a, err := i.stack.Top()
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-			if a == -1 {
-				interpret := true
-				// true code
-				// get the next word and process it,
-				for i.environments[len(i.environments)-1].Scan() {
-					w := i.environments[len(i.environments)-1].Text()
-					if w == "then" {
-						break
-					} else if w == "else" {
-						interpret = false
-					} else if interpret {
-						i.Interpret(w)
-					}
-				}
-			} else {
-				// if there is an else
-				// skip everything until the else, then interpret
-				for i.environments[len(i.environments)-1].Scan() {
-					w := i.environments[len(i.environments)-1].Text()
-					if w == "else" {
-						break
-					} else if w == "then" {
-						// if no else, return early
-						return
-					}
-				}
-				var foundThen bool
-				for i.environments[len(i.environments)-1].Scan() && !foundThen {
-					w := i.environments[len(i.environments)-1].Text()
-					if w == "then" {
-						foundThen = true
-					} else {
-						i.Interpret(w)
-					}
-				}
-				if !foundThen {
-					panic("missing 'then'")
-				}
+			v, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if err := i.memory.StoreByte(addr, v); err != nil {
+				panic(err.Error())
 			}
 		},
-	}
-
-	// do loop
-	i.dictionary["do"] = ExecutableToken{
-		name: "do",
+	})
+	i.define(&ExecutableToken{
+		name:   "cells",
+		effect: "( n1 -- n2 )",
 		primitive: func() {
-			var words []string
-
-			// grab string to 'loop'
-			for i.environments[len(i.environments)-1].Scan() {
-				w := i.environments[len(i.environments)-1].Text()
-				if w == "loop" {
-					break
-				} else {
-					words = append(words, w)
-				}
-			}
-			definition := strings.Join(words, " ")
-
-			// get the index and limit from the data stack
-			start, err := i.stack.Top()
+			n, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-
-			end, err := i.stack.Top()
+			i.stack.Push(n * CellSize)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "cell+",
+		effect: "( addr1 -- addr2 )",
+		primitive: func() {
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
 			i.stack.Pop()
-
-			for index := start; index < end; index++ {
-				i.loopStack.Push(index)
-				s := bufio.NewScanner(strings.NewReader(definition))
-				s.Split(bufio.ScanWords)
-				i.environments = append(i.environments, s)
-
-				for i.environments[len(i.environments)-1].Scan() {
-					t := i.environments[len(i.environments)-1].Text()
-					i.Interpret(t)
-				}
-
-				i.environments = i.environments[:len(i.environments)-1]
-				i.loopStack.Pop()
+			i.stack.Push(addr + CellSize)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "chars",
+		effect: "( n1 -- n2 )",
+		primitive: func() {
+			// characters are a single byte, so this leaves n unchanged
+			if _, err := i.stack.Top(); err != nil {
+				log.Fatal(err)
 			}
 		},
-	}
-	i.dictionary["i"] = ExecutableToken{
-		name: "i",
+	})
+	i.define(&ExecutableToken{
+		name:   "char+",
+		effect: "( addr1 -- addr2 )",
 		primitive: func() {
-			v, err := i.loopStack.Top()
+			addr, err := i.stack.Top()
 			if err != nil {
 				log.Fatal(err)
 			}
-			i.stack.Push(v)
+			i.stack.Pop()
+			i.stack.Push(addr + 1)
 		},
-	}
-
-	return &i
-}
-
-func (i *Interpreter) Interpret(word string) {
-	defer func() {
-		if r := recover(); r != nil {
-			_, err := fmt.Fprintf(i.out, "%s", r)
+	})
+	i.define(&ExecutableToken{
+		name:   "create",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
 			if err != nil {
 				log.Fatal(err)
 			}
-		}
-	}()
-
-	if xt, ok := i.dictionary[word]; ok {
-		xt.primitive()
-	} else {
-		v, err := strconv.ParseInt(word, 10, 64)
-		if err == nil {
-			i.stack.Push(int(v))
-		} else {
-			_, err := fmt.Fprintf(i.out, "%s ?\n", word)
+			i.memory.Align()
+			addr := i.memory.Here()
+			i.define(&ExecutableToken{
+				name: name,
+				primitive: func() {
+					i.stack.Push(addr)
+				},
+				body:    addr,
+				created: true,
+			})
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "variable",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
 			if err != nil {
 				log.Fatal(err)
 			}
-		}
-	}
-}
-
-func (i *Interpreter) Prompt() {
-	for _, v := range i.stack.items {
-		_, err := fmt.Fprintf(i.out, "%d ", v)
+			i.memory.Align()
+			addr := i.memory.Here()
+			i.define(&ExecutableToken{
+				name: name,
+				primitive: func() {
+					i.stack.Push(addr)
+				},
+				body:    addr,
+				created: true,
+			})
+			_ = i.memory.Allot(CellSize)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "constant",
+		effect: "( n -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			v, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			i.define(&ExecutableToken{
+				name: name,
+				primitive: func() {
+					i.stack.Push(v)
+				},
+			})
+		},
+	})
+
+	// Structures
+	i.define(&ExecutableToken{
+		name:   "begin-structure",
+		effect: "( -- 0 )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			// the size is filled in by end-structure
+			size := new(int)
+			i.structures.Push(size)
+			i.define(&ExecutableToken{
+				name: name,
+				primitive: func() {
+					i.stack.Push(*size)
+				},
+			})
+			i.stack.Push(0)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "end-structure",
+		effect: "( n -- )",
+		primitive: func() {
+			size, err := i.structures.Top()
+			if err != nil {
+				panic("end-structure without begin-structure")
+			}
+			i.structures.Pop()
+			n, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			*size = n
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "+field",
+		effect: "( n1 n2 -- n3 )",
+		primitive: func() {
+			size, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			offset, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			i.field(offset, size)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "field:",
+		effect: "( n1 -- n2 )",
+		primitive: func() {
+			offset, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			i.field(Aligned(offset), CellSize)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "cfield:",
+		effect: "( n1 -- n2 )",
+		primitive: func() {
+			offset, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			i.field(offset, 1)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "ffield:",
+		effect: "( n1 -- n2 )",
+		primitive: func() {
+			// floats are not supported yet, but are cell sized and aligned
+			offset, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			i.field(Aligned(offset), CellSize)
+		},
+	})
+
+	// Execution tokens
+	i.define(&ExecutableToken{
+		name:   "'",
+		effect: "( -- xt )",
+		primitive: func() {
+			i.stack.Push(i.tick().xt)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "[']",
+		effect:    "( -- xt )",
+		immediate: true,
+		primitive: func() {
+			// definitions are interpreted from their source, so this is
+			// the same as '
+			i.stack.Push(i.tick().xt)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "execute",
+		effect: "( i*x xt -- j*x )",
+		primitive: func() {
+			xt := i.popExecutionToken()
+			xt.primitive()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   ">body",
+		effect: "( xt -- addr )",
+		primitive: func() {
+			xt := i.popExecutionToken()
+			if !xt.created {
+				panic(fmt.Sprintf("%s is not a created word", xt.name))
+			}
+			i.stack.Push(xt.body)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "defer",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			word := &ExecutableToken{
+				name:     name,
+				deferred: true,
+			}
+			word.primitive = func() {
+				if word.action == nil {
+					panic(fmt.Sprintf("%s is an uninitialized deferred word", name))
+				}
+				word.action.primitive()
+			}
+			i.define(word)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "is",
+		effect:    "( xt -- )",
+		immediate: true,
+		primitive: func() {
+			word := i.tick()
+			if !word.deferred {
+				panic(fmt.Sprintf("%s is not a deferred word", word.name))
+			}
+			word.action = i.popExecutionToken()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "action-of",
+		effect:    "( -- xt )",
+		immediate: true,
+		primitive: func() {
+			word := i.tick()
+			if !word.deferred {
+				panic(fmt.Sprintf("%s is not a deferred word", word.name))
+			}
+			if word.action == nil {
+				panic(fmt.Sprintf("%s is an uninitialized deferred word", word.name))
+			}
+			i.stack.Push(word.action.xt)
+		},
+	})
+
+	// Strings
+	i.define(&ExecutableToken{
+		name:      "s\"",
+		effect:    "( -- c-addr u )",
+		immediate: true,
+		primitive: func() {
+			here := i.site()
+			s := i.parseString()
+			i.stack.Push(i.stringLiteral(here, s) + 1)
+			i.stack.Push(len(s))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "s\\\"",
+		effect:    "( -- c-addr u )",
+		immediate: true,
+		primitive: func() {
+			here := i.site()
+			s := i.parseEscapedString()
+			i.stack.Push(i.stringLiteral(here, s) + 1)
+			i.stack.Push(len(s))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "c\"",
+		effect:    "( -- c-addr )",
+		immediate: true,
+		primitive: func() {
+			here := i.site()
+			s := i.parseString()
+			if len(s) > 255 {
+				panic("counted string too long")
+			}
+			i.stack.Push(i.stringLiteral(here, s))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "count",
+		effect: "( c-addr1 -- c-addr2 u )",
+		primitive: func() {
+			addr, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			n, err := i.memory.FetchByte(addr)
+			if err != nil {
+				panic(err.Error())
+			}
+			i.stack.Push(addr + 1)
+			i.stack.Push(n)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "type",
+		effect: "( c-addr u -- )",
+		primitive: func() {
+			_, err := fmt.Fprint(i.out, i.popString())
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	// Dictionary
+	i.define(&ExecutableToken{
+		name:   "words",
+		effect: "( -- )",
+		primitive: func() {
+			i.printWords("")
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "words-like",
+		effect: "( -- )",
+		primitive: func() {
+			filter, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.printWords(filter)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "see",
+		effect: "( -- )",
+		primitive: func() {
+			word := i.tick()
+			var source string
+			if word.colon {
+				source = fmt.Sprintf(": %s %s ;", word.name, word.definition)
+				if word.immediate {
+					source += " immediate"
+				}
+				if word.file != "" {
+					source = fmt.Sprintf("( %s:%d )\n%s", word.file, word.line, source)
+				}
+			} else {
+				source = fmt.Sprintf("%s %s is a primitive", word.name, word.effect)
+				if word.immediate {
+					source += ", immediate"
+				}
+			}
+			_, err := fmt.Fprintln(i.out, source)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	// Debugging
+	i.define(&ExecutableToken{
+		name:   "debug",
+		effect: "( -- )",
+		primitive: func() {
+			i.debugWord(i.tick())
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "break",
+		effect: "( -- )",
+		primitive: func() {
+			spec, err := i.Word()
+			if err != nil {
+				panic("missing breakpoint\n")
+			}
+			i.addBreakpoint(spec)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "unbreak",
+		effect: "( -- )",
+		primitive: func() {
+			spec, err := i.Word()
+			if err != nil {
+				panic("missing breakpoint\n")
+			}
+			i.removeBreakpoint(spec)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "breakpoints",
+		effect: "( -- )",
+		primitive: func() {
+			i.printBreakpoints()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "trace",
+		effect: "( -- )",
+		primitive: func() {
+			switch setting, _ := i.Word(); setting {
+			case "on":
+				i.SetTrace(true)
+			case "off":
+				i.SetTrace(false)
+			default:
+				panic("trace must be followed by on or off\n")
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "trace-level",
+		effect: "( n -- )",
+		primitive: func() {
+			level, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if level < TraceWords || level > TraceLocations {
+				panic("invalid trace level\n")
+			}
+			i.SetTraceLevel(level)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "trace-only",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				panic("missing word to trace\n")
+			}
+			i.TraceOnly(name)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "trace-all",
+		effect: "( -- )",
+		primitive: func() {
+			i.TraceOnly()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "profile-start",
+		effect: "( -- )",
+		primitive: func() {
+			i.StartProfile()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "profile-stop",
+		effect: "( -- )",
+		primitive: func() {
+			i.StopProfile()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "profile-report",
+		effect: "( -- )",
+		primitive: func() {
+			if err := i.WriteProfileReport(i.out); err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "coverage-start",
+		effect: "( -- )",
+		primitive: func() {
+			i.StartCoverage()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "coverage-stop",
+		effect: "( -- )",
+		primitive: func() {
+			i.StopCoverage()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "coverage-report",
+		effect: "( -- )",
+		primitive: func() {
+			if err := i.WriteCoverageReport(i.out); err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	i.define(&ExecutableToken{
+		name:   "immediate",
+		effect: "( -- )",
+		primitive: func() {
+			i.xts[len(i.xts)-1].immediate = true
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "find",
+		effect: "( c-addr -- c-addr 0 | xt 1 | xt -1 )",
+		primitive: func() {
+			addr, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			n, err := i.memory.FetchByte(addr)
+			if err != nil {
+				panic(err.Error())
+			}
+			i.stack.Push(addr + 1)
+			i.stack.Push(n)
+			word, ok := i.lookup(i.popString())
+			if !ok {
+				i.stack.Push(addr)
+				i.stack.Push(0)
+				return
+			}
+			i.pushFound(word)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "search-wordlist",
+		effect: "( c-addr u wid -- 0 | xt 1 | xt -1 )",
+		primitive: func() {
+			wordlist := i.popWordlist()
+			word, ok := wordlist.Find(i.popString())
+			if !ok {
+				i.stack.Push(0)
+				return
+			}
+			i.pushFound(word)
+		},
+	})
+
+	// Wordlists and the search order
+	i.define(&ExecutableToken{
+		name:   "forth-wordlist",
+		effect: "( -- wid )",
+		primitive: func() {
+			i.stack.Push(0)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "wordlist",
+		effect: "( -- wid )",
+		primitive: func() {
+			i.wordlists = append(i.wordlists, NewWordlist(""))
+			i.stack.Push(len(i.wordlists) - 1)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "vocabulary",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.wordlists = append(i.wordlists, NewWordlist(name))
+			wid := len(i.wordlists) - 1
+			i.define(&ExecutableToken{
+				name: name,
+				primitive: func() {
+					i.setContext(wid)
+				},
+			})
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "forth",
+		effect: "( -- )",
+		primitive: func() {
+			i.setContext(0)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "get-order",
+		effect: "( -- widn .. wid1 n )",
+		primitive: func() {
+			for n := len(i.order) - 1; n >= 0; n-- {
+				i.stack.Push(i.order[n])
+			}
+			i.stack.Push(len(i.order))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "set-order",
+		effect: "( widn .. wid1 n -- )",
+		primitive: func() {
+			n, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if n == -1 {
+				i.order = minimumOrder()
+				return
+			}
+			order := make([]int, n)
+			for k := range n {
+				wid, err := i.stack.Top()
+				if err != nil {
+					log.Fatal(err)
+				}
+				if wid < 0 || wid >= len(i.wordlists) {
+					panic(fmt.Sprintf("invalid wordlist %d", wid))
+				}
+				i.stack.Pop()
+				order[k] = wid
+			}
+			i.order = order
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "also",
+		effect: "( -- )",
+		primitive: func() {
+			if len(i.order) == 0 {
+				panic("search order is empty")
+			}
+			i.order = append([]int{i.order[0]}, i.order...)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "only",
+		effect: "( -- )",
+		primitive: func() {
+			i.order = minimumOrder()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "previous",
+		effect: "( -- )",
+		primitive: func() {
+			if len(i.order) == 0 {
+				panic("search order is empty")
+			}
+			i.order = i.order[1:]
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "definitions",
+		effect: "( -- )",
+		primitive: func() {
+			if len(i.order) == 0 {
+				panic("search order is empty")
+			}
+			i.current = i.order[0]
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "get-current",
+		effect: "( -- wid )",
+		primitive: func() {
+			i.stack.Push(i.current)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "set-current",
+		effect: "( wid -- )",
+		primitive: func() {
+			wid, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if wid < 0 || wid >= len(i.wordlists) {
+				panic(fmt.Sprintf("invalid wordlist %d", wid))
+			}
+			i.stack.Pop()
+			i.current = wid
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "order",
+		effect: "( -- )",
+		primitive: func() {
+			names := []string{}
+			for _, wid := range i.order {
+				names = append(names, i.wordlistName(wid))
+			}
+			_, err := fmt.Fprintf(i.out, "%s current: %s\n", strings.Join(names, " "), i.wordlistName(i.current))
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	// Rolling back the dictionary
+	i.define(&ExecutableToken{
+		name:   "marker",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			state := i.dictionaryState()
+			memory := append([]byte{}, i.memory.bytes...)
+			i.define(&ExecutableToken{
+				name: name,
+				primitive: func() {
+					i.rollback(state)
+					i.restoreMemory(memory)
+				},
+			})
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "forget",
+		effect: "( -- )",
+		primitive: func() {
+			word := i.tick()
+			if word.xt < i.builtins {
+				panic(fmt.Sprintf("cannot forget built-in word %s", word.name))
+			}
+			state := i.dictionaryState()
+			state.xts = word.xt
+			for wid, wordlist := range i.wordlists {
+				n := 0
+				for n < len(wordlist.words) && wordlist.words[n].xt < word.xt {
+					n++
+				}
+				state.wordlists[wid] = n
+			}
+			state.here = word.here
+			i.rollback(state)
+		},
+	})
+
+	// Source inclusion
+	i.define(&ExecutableToken{
+		name:   "include",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := i.Include(name); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "included",
+		effect: "( c-addr u -- )",
+		primitive: func() {
+			if err := i.Include(i.popString()); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "require",
+		effect: "( -- )",
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := i.Require(name); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "required",
+		effect: "( c-addr u -- )",
+		primitive: func() {
+			if err := i.Require(i.popString()); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+
+	// File access
+	i.define(&ExecutableToken{
+		name:   "r/o",
+		effect: "( -- fam )",
+		primitive: func() {
+			i.stack.Push(os.O_RDONLY)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "w/o",
+		effect: "( -- fam )",
+		primitive: func() {
+			i.stack.Push(os.O_WRONLY)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "r/w",
+		effect: "( -- fam )",
+		primitive: func() {
+			i.stack.Push(os.O_RDWR)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "bin",
+		effect: "( fam1 -- fam2 )",
+		primitive: func() {
+			// files are always read and written as bytes, so this leaves
+			// fam unchanged
+			if _, err := i.stack.Top(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "open-file",
+		effect: "( c-addr u fam -- fileid ior )",
+		primitive: func() {
+			fam, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			id, err := i.openFile(i.popString(), fam)
+			i.stack.Push(id)
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "create-file",
+		effect: "( c-addr u fam -- fileid ior )",
+		primitive: func() {
+			fam, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			id, err := i.openFile(i.popString(), fam|os.O_CREATE|os.O_TRUNC)
+			i.stack.Push(id)
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "close-file",
+		effect: "( fileid -- ior )",
+		primitive: func() {
+			id, f, err := i.popFile()
+			if err == nil {
+				err = f.Close()
+				delete(i.files, id)
+			}
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "read-file",
+		effect: "( c-addr u1 fileid -- u2 ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			n, err2 := i.stack.Top()
+			if err2 != nil {
+				log.Fatal(err2)
+			}
+			i.stack.Pop()
+			addr, err2 := i.stack.Top()
+			if err2 != nil {
+				log.Fatal(err2)
+			}
+			i.stack.Pop()
+			read := 0
+			if err == nil {
+				b := make([]byte, n)
+				read, err = io.ReadFull(f, b)
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					err = nil
+				}
+				if err == nil {
+					err = i.memory.Write(addr, b[:read])
+				}
+			}
+			i.stack.Push(read)
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "read-line",
+		effect: "( c-addr u1 fileid -- u2 flag ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			n, err2 := i.stack.Top()
+			if err2 != nil {
+				log.Fatal(err2)
+			}
+			i.stack.Pop()
+			addr, err2 := i.stack.Top()
+			if err2 != nil {
+				log.Fatal(err2)
+			}
+			i.stack.Pop()
+			read, more := 0, false
+			if err == nil {
+				read, more, err = i.readLine(f, addr, n)
+			}
+			i.stack.Push(read)
+			if more {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "write-file",
+		effect: "( c-addr u fileid -- ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			s := i.popString()
+			if err == nil {
+				_, err = f.WriteString(s)
+			}
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "write-line",
+		effect: "( c-addr u fileid -- ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			s := i.popString()
+			if err == nil {
+				_, err = f.WriteString(s + "\n")
+			}
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "file-size",
+		effect: "( fileid -- ud ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			var size int64
+			if err == nil {
+				var info os.FileInfo
+				info, err = f.Stat()
+				if err == nil {
+					size = info.Size()
+				}
+			}
+			// ud is a double cell number, the high cell is always 0
+			i.stack.Push(int(size))
+			i.stack.Push(0)
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "file-position",
+		effect: "( fileid -- ud ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			var position int64
+			if err == nil {
+				position, err = f.Seek(0, io.SeekCurrent)
+			}
+			i.stack.Push(int(position))
+			i.stack.Push(0)
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "reposition-file",
+		effect: "( ud fileid -- ior )",
+		primitive: func() {
+			_, f, err := i.popFile()
+			// ignore the high cell of ud
+			if _, err2 := i.stack.Top(); err2 != nil {
+				log.Fatal(err2)
+			}
+			i.stack.Pop()
+			position, err2 := i.stack.Top()
+			if err2 != nil {
+				log.Fatal(err2)
+			}
+			i.stack.Pop()
+			if err == nil {
+				_, err = f.Seek(int64(position), io.SeekStart)
+			}
+			i.stack.Push(ior(err))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "delete-file",
+		effect: "( c-addr u -- ior )",
+		primitive: func() {
+			i.stack.Push(ior(os.Remove(i.popString())))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "rename-file",
+		effect: "( c-addr1 u1 c-addr2 u2 -- ior )",
+		primitive: func() {
+			to := i.popString()
+			from := i.popString()
+			i.stack.Push(ior(os.Rename(from, to)))
+		},
+	})
+
+	// Blocks
+	i.define(&ExecutableToken{
+		name:   "block",
+		effect: "( u -- a-addr )",
+		primitive: func() {
+			u, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			n, err := i.assignBuffer(u, true)
+			if err != nil {
+				panic(err.Error())
+			}
+			i.stack.Push(i.blocks.buffers[n].addr)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "buffer",
+		effect: "( u -- a-addr )",
+		primitive: func() {
+			u, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			n, err := i.assignBuffer(u, false)
+			if err != nil {
+				panic(err.Error())
+			}
+			i.stack.Push(i.blocks.buffers[n].addr)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "update",
+		effect: "( -- )",
+		primitive: func() {
+			if i.blocks == nil || i.blocks.current == -1 {
+				panic("no current block buffer")
+			}
+			i.blocks.buffers[i.blocks.current].dirty = true
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "save-buffers",
+		effect: "( -- )",
+		primitive: func() {
+			if err := i.FlushBlocks(); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "flush",
+		effect: "( -- )",
+		primitive: func() {
+			if err := i.FlushBlocks(); err != nil {
+				panic(err.Error())
+			}
+			i.emptyBuffers()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "empty-buffers",
+		effect: "( -- )",
+		primitive: func() {
+			i.emptyBuffers()
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "list",
+		effect: "( u -- )",
+		primitive: func() {
+			u, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			text, err := i.blockText(u)
+			if err != nil {
+				panic(err.Error())
+			}
+			_, err = fmt.Fprintf(i.out, "block %d\n", u)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for line := 0; line < blockLines; line++ {
+				l := strings.TrimRight(text[line*blockLine:(line+1)*blockLine], " ")
+				_, err := fmt.Fprintf(i.out, "%2d %s\n", line, l)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "load",
+		effect: "( i*x u -- j*x )",
+		primitive: func() {
+			u, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if err := i.loadBlock(u); err != nil {
+				panic(err.Error())
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "thru",
+		effect: "( i*x u1 u2 -- j*x )",
+		primitive: func() {
+			last, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			first, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			for u := first; u <= last; u++ {
+				if err := i.loadBlock(u); err != nil {
+					panic(err.Error())
+				}
+			}
+		},
+	})
+
+	// Input
+	i.define(&ExecutableToken{
+		name:   "key",
+		effect: "( -- char )",
+		primitive: func() {
+			c, err := i.input.ReadByte()
+			if err != nil {
+				i.stack.Push(-1)
+				return
+			}
+			i.stack.Push(int(c))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "key?",
+		effect: "( -- flag )",
+		primitive: func() {
+			if i.keyAvailable() {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "ekey",
+		effect: "( -- x )",
+		primitive: func() {
+			r, _, err := i.input.ReadRune()
+			if err != nil {
+				i.stack.Push(-1)
+				return
+			}
+			i.stack.Push(int(r))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "accept",
+		effect: "( c-addr +n1 -- +n2 )",
+		primitive: func() {
+			n, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			addr, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			line, err := i.readInput()
+			if err != nil && err != io.EOF {
+				panic(err.Error())
+			}
+			if len(line) > n {
+				line = line[:n]
+			}
+			if err := i.memory.Write(addr, []byte(line)); err != nil {
+				panic(err.Error())
+			}
+			i.stack.Push(len(line))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "refill",
+		effect: "( -- flag )",
+		primitive: func() {
+			// files move on to their next line, the user input device
+			// reads one from the input
+			if s := i.inputSource(); s != i.environments[0] {
+				if s.nextLine() {
+					i.stack.Push(-1)
+				} else {
+					i.stack.Push(0)
+				}
+				return
+			}
+			if err := i.Refill(); err != nil {
+				i.stack.Push(0)
+				return
+			}
+			i.stack.Push(-1)
+		},
+	})
+
+	// Parsing
+	i.define(&ExecutableToken{
+		name:   "source",
+		effect: "( -- c-addr u )",
+		primitive: func() {
+			s := i.sourceLine()
+			i.stack.Push(i.inputBuffer)
+			i.stack.Push(len(s.current()))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   ">in",
+		effect: "( -- a-addr )",
+		primitive: func() {
+			i.stack.Push(i.toIn)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "parse",
+		effect: "( char \"ccc<char>\" -- c-addr u )",
+		primitive: func() {
+			c, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			start, end, _ := i.sourceLine().parse(byte(c))
+			i.stack.Push(i.inputBuffer + start)
+			i.stack.Push(end - start)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "parse-name",
+		effect: "( \"<spaces>name<space>\" -- c-addr u )",
+		primitive: func() {
+			start, end := i.sourceLine().parseName()
+			i.stack.Push(i.inputBuffer + start)
+			i.stack.Push(end - start)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "word",
+		effect: "( char \"<chars>ccc<char>\" -- c-addr )",
+		primitive: func() {
+			c, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			s := i.sourceLine()
+			start, end := s.word(byte(c))
+			text := s.current()[start:end]
+			if len(text) >= wordBufferSize {
+				panic("word too long")
+			}
+			b := append([]byte{byte(len(text))}, text...)
+			if err := i.memory.Write(i.wordBuffer, b); err != nil {
+				panic(err.Error())
+			}
+			i.stack.Push(i.wordBuffer)
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "char",
+		effect: "( \"<spaces>name\" -- char )",
+		primitive: func() {
+			s := i.inputSource()
+			start, end := s.parseName()
+			if start == end {
+				panic("missing name")
+			}
+			i.stack.Push(int(s.current()[start]))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "[char]",
+		effect:    "( \"<spaces>name\" -- char )",
+		immediate: true,
+		primitive: func() {
+			name, err := i.Word()
+			if err != nil {
+				panic("missing name")
+			}
+			i.stack.Push(int(name[0]))
+		},
+	})
+
+	// Comments
+	i.define(&ExecutableToken{
+		name:      "(",
+		effect:    "( -- )",
+		immediate: true,
+		primitive: func() {
+			i.skipComment(i.environments[len(i.environments)-1])
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      "\\",
+		effect:    "( -- )",
+		immediate: true,
+		primitive: func() {
+			e := i.environments[len(i.environments)-1]
+			e.setPosition(len(e.current()))
+		},
+	})
+	i.define(&ExecutableToken{
+		name:      ".(",
+		effect:    "( -- )",
+		immediate: true,
+		primitive: func() {
+			e := i.environments[len(i.environments)-1]
+			start, end, _ := e.parse(')')
+			_, err := fmt.Fprint(i.out, e.current()[start:end])
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	// Comparrisions
+	i.define(&ExecutableToken{
+		name:   "=",
+		effect: "( n1 n2 -- flag )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			b, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if a == b {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "<",
+		effect: "( n1 n2 -- flag )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			b, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if b < a {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   ">",
+		effect: "( n1 n2 -- flag )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			b, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if b > a {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "<>",
+		effect: "( n1 n2 -- flag )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			b, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if b != a {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+
+	// Boolean Operators
+	i.define(&ExecutableToken{
+		name:   "and",
+		effect: "( n1 n2 -- flag )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			b, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if b == -1 && a == -1 {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "or",
+		effect: "( n1 n2 -- flag )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			b, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if b == -1 || a == -1 {
+				i.stack.Push(-1)
+			} else {
+				i.stack.Push(0)
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "invert",
+		effect: "( flag1 -- flag2 )",
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if a == -1 {
+				i.stack.Push(0)
+			} else {
+				i.stack.Push(-1)
+			}
+		},
+	})
+
+	// if
+	i.define(&ExecutableToken{
+		name:      "if",
+		effect:    "( flag -- )",
+		immediate: true,
+		primitive: func() {
+			a, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			if a == -1 {
+				interpret := true
+				depth := 0
+				// true code
+				// get the next word and process it, skipping any nested
+				// if blocks in the else part
+				for i.environments[len(i.environments)-1].Scan() {
+					w := i.environments[len(i.environments)-1].Text()
+					if w == "then" && depth == 0 {
+						break
+					} else if w == "else" && depth == 0 {
+						interpret = false
+					} else if interpret {
+						i.Interpret(w)
+					} else if w == "if" {
+						depth++
+					} else if w == "then" {
+						depth--
+					}
+				}
+			} else {
+				// if there is an else
+				// skip everything until the else, then interpret
+				depth := 0
+				for i.environments[len(i.environments)-1].Scan() {
+					w := i.environments[len(i.environments)-1].Text()
+					if w == "else" && depth == 0 {
+						break
+					} else if w == "then" && depth == 0 {
+						// if no else, return early
+						return
+					} else if w == "if" {
+						depth++
+					} else if w == "then" {
+						depth--
+					}
+				}
+				var foundThen bool
+				for !foundThen && i.environments[len(i.environments)-1].Scan() {
+					w := i.environments[len(i.environments)-1].Text()
+					if w == "then" {
+						foundThen = true
+					} else {
+						i.Interpret(w)
+					}
+				}
+				if !foundThen {
+					panic("missing 'then'")
+				}
+			}
+		},
+	})
+
+	// do loop
+	i.define(&ExecutableToken{
+		name:      "do",
+		effect:    "( limit index -- )",
+		immediate: true,
+		primitive: func() {
+			// grab string to 'loop'
+			e := i.environments[len(i.environments)-1]
+			line, column := e.line, e.sourceColumn(e.position())
+			depth := 0
+			definition, _ := i.collect(func(w string) bool {
+				if w == "do" {
+					depth++
+				} else if w == "loop" {
+					if depth == 0 {
+						return true
+					}
+					depth--
+				}
+				return false
+			})
+
+			// get the index and limit from the data stack
+			start, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+
+			end, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+
+			for index := start; index < end; index++ {
+				i.loopStack.Push(index)
+				body := newEnvironment("", definition, line)
+				body.column = column
+				i.environments = append(i.environments, body)
+
+				for i.environments[len(i.environments)-1].Scan() {
+					t := i.environments[len(i.environments)-1].Text()
+					i.Interpret(t)
+				}
+
+				i.environments = i.environments[:len(i.environments)-1]
+				i.loopStack.Pop()
+			}
+		},
+	})
+	i.define(&ExecutableToken{
+		name:   "i",
+		effect: "( -- n )",
+		primitive: func() {
+			v, err := i.loopStack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Push(v)
+		},
+	})
+
+	i.builtins = len(i.xts)
+
+	return &i
+}
+
+func (i *Interpreter) Interpret(word string) {
+	outermost := i.running.Load() == 0
+	environments, including := len(i.environments), len(i.including)
+	defer func() {
+		if r := recover(); r != nil {
+			// an interrupt abandons everything being run, not just this word
+			if r == errInterrupted || r == errBye {
+				if !outermost {
+					panic(r)
+				}
+				i.unwind(environments, including)
+			}
+			if r == errBye {
+				i.bye = true
+				return
+			}
+			// messages end the line they're written on, whether or not they
+			// end with a newline
+			message := fmt.Sprint(r)
+			if !strings.HasSuffix(message, "\n") {
+				message += "\n"
+			}
+			_, err := fmt.Fprint(i.errOut, i.location(), message)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}()
+
+	if i.interrupted.Swap(false) {
+		panic(errInterrupted)
+	}
+
+	if len(i.hooks) > 0 {
+		e := i.event(word)
+		for _, hook := range i.hooks {
+			if hook.Before != nil {
+				hook.Before(e)
+			}
+		}
+		defer func() {
+			for _, hook := range i.hooks {
+				if hook.After != nil {
+					hook.After(e)
+				}
+			}
+		}()
+	}
+
+	if f, err := i.frames.Top(); err == nil {
+		if v, ok := f.locals[word]; ok {
+			i.stack.Push(v)
+			return
+		}
+	}
+
+	if xt, ok := i.resolve(word); ok {
+		i.running.Add(1)
+		defer i.running.Add(-1)
+		xt.primitive()
+	} else {
+		v, err := strconv.ParseInt(word, 10, 64)
+		if err == nil {
+			i.stack.Push(int(v))
+		} else {
+			_, err := fmt.Fprintf(i.errOut, "%s%s ?\n", i.location(), word)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// define adds the word to the current wordlist, giving it the next execution
+// token.
+func (i *Interpreter) define(word *ExecutableToken) {
+	if existing, ok := i.wordlists[i.current].Find(word.name); ok {
+		if i.protect && existing.xt < i.builtins {
+			panic(fmt.Sprintf("cannot redefine built-in word %s", word.name))
+		}
+		_, err := fmt.Fprintf(i.errOut, "redefined %s\n", word.name)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	i.register(word)
+	i.wordlists[i.current].Add(word)
+}
+
+// lookup finds the word with the given name in the wordlists of the search
+// order.
+func (i *Interpreter) lookup(name string) (*ExecutableToken, bool) {
+	for _, wid := range i.order {
+		if word, ok := i.wordlists[wid].Find(name); ok {
+			return word, true
+		}
+	}
+	return nil, false
+}
+
+// minimumOrder is the search order started with and set by only. Forth is in
+// it twice, so making a vocabulary the first wordlist searched leaves forth,
+// and the words to change the search order back, under it.
+func minimumOrder() []int {
+	return []int{0, 0}
+}
+
+// setContext replaces the first wordlist in the search order.
+func (i *Interpreter) setContext(wid int) {
+	if len(i.order) == 0 {
+		i.order = []int{wid}
+	} else {
+		i.order[0] = wid
+	}
+}
+
+func (i *Interpreter) wordlistName(wid int) string {
+	if i.wordlists[wid].name != "" {
+		return i.wordlists[wid].name
+	}
+	return strconv.Itoa(wid)
+}
+
+// popWordlist pops a wordlist identifier off the stack, returning the wordlist
+// it refers to.
+func (i *Interpreter) popWordlist() *Wordlist {
+	wid, err := i.stack.Top()
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.stack.Pop()
+	if wid < 0 || wid >= len(i.wordlists) {
+		panic(fmt.Sprintf("invalid wordlist %d", wid))
+	}
+	return i.wordlists[wid]
+}
+
+// dictionaryState records the size of the dictionary and data space, so
+// they can be rolled back to it.
+type dictionaryState struct {
+	xts       int
+	wordlists []int
+	order     []int
+	current   int
+	here      int
+}
+
+func (i *Interpreter) dictionaryState() dictionaryState {
+	state := dictionaryState{
+		xts:     len(i.xts),
+		order:   append([]int{}, i.order...),
+		current: i.current,
+		here:    i.memory.Here(),
+	}
+	for _, wordlist := range i.wordlists {
+		state.wordlists = append(state.wordlists, len(wordlist.words))
+	}
+	return state
+}
+
+// rollback removes everything defined since the state was recorded.
+func (i *Interpreter) rollback(state dictionaryState) {
+	i.xts = i.xts[:state.xts]
+	i.wordlists = i.wordlists[:len(state.wordlists)]
+	for wid, wordlist := range i.wordlists {
+		wordlist.Truncate(state.wordlists[wid])
+	}
+	i.order = append([]int{}, state.order...)
+	i.current = state.current
+
+	if state.here < i.memory.Here() {
+		_ = i.memory.Allot(state.here - i.memory.Here())
+	}
+	for here, addr := range i.literals {
+		if addr >= state.here {
+			delete(i.literals, here)
+		}
+	}
+	for here, quotation := range i.quotations {
+		if quotation.xt >= state.xts {
+			delete(i.quotations, here)
+		}
+	}
+	for _, word := range i.xts {
+		if word.action != nil && word.action.xt >= state.xts {
+			word.action = nil
+		}
+	}
+}
+
+// restoreMemory puts back the contents of data space recorded by a marker,
+// leaving >in and the block buffers, which belong to the input and the block
+// file rather than the dictionary, as they are.
+func (i *Interpreter) restoreMemory(memory []byte) {
+	in, _ := i.memory.Read(i.toIn, CellSize)
+	var buffers [][]byte
+	if i.blocks != nil {
+		for _, buffer := range i.blocks.buffers {
+			b, _ := i.memory.Read(buffer.addr, BlockSize)
+			buffers = append(buffers, b)
+		}
+	}
+	copy(i.memory.bytes, memory)
+	_ = i.memory.Write(i.toIn, in)
+	for n, b := range buffers {
+		_ = i.memory.Write(i.blocks.buffers[n].addr, b)
+	}
+}
+
+// register gives the word the next execution token without adding it to the
+// dictionary.
+func (i *Interpreter) register(word *ExecutableToken) {
+	word.xt = len(i.xts)
+	word.here = i.memory.Here()
+	i.xts = append(i.xts, word)
+}
+
+// colonDefinition creates a word that interprets the definition each time it
+// is executed, with its own locals.
+func (i *Interpreter) colonDefinition(name string, definition string) *ExecutableToken {
+	// a comment at the start of the definition is its stack effect
+	var effect string
+	if strings.HasPrefix(definition, "( ") {
+		if end := strings.Index(definition, ")"); end != -1 {
+			effect = definition[:end+1]
+		}
+	}
+
+	bindings := make(map[string]*ExecutableToken)
+	for _, name := range strings.Fields(definition) {
+		if word, ok := i.resolve(name); ok {
+			bindings[name] = word
+		}
+	}
+
+	word := &ExecutableToken{
+		name:       name,
+		effect:     effect,
+		colon:      true,
+		definition: definition,
+	}
+	word.primitive = func() {
+		body := newEnvironment("", definition, word.first)
+		body.column = word.column
+		i.environments = append(i.environments, body)
+		i.frames.Push(&frame{
+			word:     word,
+			locals:   make(map[string]int),
+			bindings: bindings,
+		})
+		for i.environments[len(i.environments)-1].Scan() {
+			t := i.environments[len(i.environments)-1].Text()
+			i.Interpret(t)
+		}
+		i.frames.Pop()
+		i.environments = i.environments[:len(i.environments)-1]
+	}
+	return word
+}
+
+// resolve finds the word a name refers to, preferring the word it referred to
+// when the running definition was defined.
+func (i *Interpreter) resolve(name string) (*ExecutableToken, bool) {
+	if f, err := i.frames.Top(); err == nil {
+		if word, ok := f.bindings[name]; ok {
+			return word, true
+		}
+	}
+	return i.lookup(name)
+}
+
+// tick reads the next word from the input and looks it up in the dictionary.
+func (i *Interpreter) tick() *ExecutableToken {
+	name, err := i.Word()
+	if err != nil {
+		log.Fatal(err)
+	}
+	word, ok := i.resolve(name)
+	if !ok {
+		panic(fmt.Sprintf("%s ?\n", name))
+	}
+	return word
+}
+
+// popExecutionToken pops an execution token off the stack, returning the word
+// it refers to.
+func (i *Interpreter) popExecutionToken() *ExecutableToken {
+	xt, err := i.stack.Top()
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.stack.Pop()
+	if xt < 0 || xt >= len(i.xts) {
+		panic(fmt.Sprintf("invalid execution token %d", xt))
+	}
+	return i.xts[xt]
+}
+
+// site is where a literal or quotation is written, in a definition or an
+// input source, so each one is only made once however many times the text
+// is read, and identical ones written in different places are kept apart.
+type site struct {
+	word   *ExecutableToken
+	source *environment
+	line   int
+	column int
+}
+
+// site returns where the word just read is written.
+func (i *Interpreter) site() site {
+	e := i.environments[len(i.environments)-1]
+	here := site{line: e.line, column: e.sourceColumn(e.start)}
+	if f, err := i.frames.Top(); err == nil {
+		here.word = f.word
+	} else {
+		here.source = i.inputSource()
+	}
+	return here
+}
+
+// stringLiteral returns the address of s, written at here, stored as a
+// counted string in data space. Each literal is only stored once, so those
+// in definitions don't use more space each time the definition runs.
+func (i *Interpreter) stringLiteral(here site, s string) int {
+	if addr, ok := i.literals[here]; ok {
+		return addr
+	}
+	addr := i.memory.Here()
+	_ = i.memory.Allot(len(s) + 1)
+	_ = i.memory.StoreByte(addr, min(len(s), 255))
+	for n := 0; n < len(s); n++ {
+		_ = i.memory.StoreByte(addr+1+n, int(s[n]))
+	}
+	i.literals[here] = addr
+	return addr
+}
+
+// popString pops a string's address and length off the stack, returning the
+// string read from data space.
+func (i *Interpreter) popString() string {
+	n, err := i.stack.Top()
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.stack.Pop()
+	addr, err := i.stack.Top()
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.stack.Pop()
+	var sb strings.Builder
+	for a := addr; a < addr+n; a++ {
+		c, err := i.memory.FetchByte(a)
+		if err != nil {
+			panic(err.Error())
+		}
+		sb.WriteByte(byte(c))
+	}
+	return sb.String()
+}
+
+// pushFound pushes the execution token of a word that was searched for, with
+// 1 if it is immediate or -1 if not.
+func (i *Interpreter) pushFound(word *ExecutableToken) {
+	i.stack.Push(word.xt)
+	if word.immediate {
+		i.stack.Push(1)
+	} else {
+		i.stack.Push(-1)
+	}
+}
+
+// printWords prints the names in the first wordlist of the search order
+// containing filter, newest first.
+func (i *Interpreter) printWords(filter string) {
+	names := []string{}
+	if len(i.order) == 0 {
+		panic("search order is empty")
+	}
+	wordlist := i.wordlists[i.order[0]]
+	for n := len(wordlist.words) - 1; n >= 0; n-- {
+		word := wordlist.words[n]
+		if !wordlist.Visible(word) || !strings.Contains(word.name, filter) {
+			continue
+		}
+		names = append(names, word.name)
+	}
+	_, err := fmt.Fprintln(i.out, strings.Join(names, " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// field defines the next word as a structure field, adding offset to the
+// address on the stack, and leaves the offset of the following field.
+func (i *Interpreter) field(offset int, size int) {
+	name, err := i.Word()
+	if err != nil {
+		log.Fatal(err)
+	}
+	i.define(&ExecutableToken{
+		name: name,
+		primitive: func() {
+			addr, err := i.stack.Top()
+			if err != nil {
+				log.Fatal(err)
+			}
+			i.stack.Pop()
+			i.stack.Push(addr + offset)
+		},
+	})
+	i.stack.Push(offset + size)
+}
+
+func (i *Interpreter) Prompt() {
+	for _, v := range i.stack.items {
+		_, err := fmt.Fprintf(i.out, "%d ", v)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
-	fmt.Print("ok> ")
+	_, err := fmt.Fprint(i.out, "ok> ")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Stack returns a copy of the data stack, the top of the stack last.
+func (i *Interpreter) Stack() []int {
+	return append([]int{}, i.stack.items...)
+}
+
+// Words returns the names of the words in the search order.
+func (i *Interpreter) Words() []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, wid := range i.order {
+		for _, word := range i.wordlists[wid].words {
+			if !seen[word.name] {
+				seen[word.name] = true
+				names = append(names, word.name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (i *Interpreter) Word() (string, error) {
+	if i.bye {
+		return "", ErrBye
+	}
 	if i.environments != nil && i.environments[len(i.environments)-1].Scan() {
 		return i.environments[len(i.environments)-1].Text(), nil
 	} else {
@@ -623,6 +2801,18 @@ func (i *Interpreter) Word() (string, error) {
 }
 
 func (i *Interpreter) SetScanLine(line string) {
-	i.environments[len(i.environments)-1] = bufio.NewScanner(strings.NewReader(line))
-	i.environments[len(i.environments)-1].Split(bufio.ScanWords)
+	e := i.environments[len(i.environments)-1]
+	e.reset(line, e.line+1)
+}
+
+// ProtectBuiltins stops the built-in words from being redefined in the
+// wordlist they are defined in when protect is true.
+func (i *Interpreter) ProtectBuiltins(protect bool) {
+	i.protect = protect
+}
+
+// SetSourceName sets the name of the source being interpreted, usually a file
+// name, which is recorded against the words it defines.
+func (i *Interpreter) SetSourceName(name string) {
+	i.environments[len(i.environments)-1].name = name
 }
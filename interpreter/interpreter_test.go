@@ -287,6 +287,21 @@ func TestConditionalsAndLoops(t *testing.T) {
 			expectedOutput: "Not Equal",
 			expectedStack:  []int{},
 		},
+		"if then else - words after then": {
+			input:          "0 if 1 else 2 then 3",
+			expectedOutput: "",
+			expectedStack:  []int{2, 3},
+		},
+		"nested if - skipped": {
+			input:          "0 if -1 if 1 then 2 else 3 then 4",
+			expectedOutput: "",
+			expectedStack:  []int{3, 4},
+		},
+		"nested if - in skipped else": {
+			input:          "-1 if 1 else -1 if 2 then 3 then 4",
+			expectedOutput: "",
+			expectedStack:  []int{1, 4},
+		},
 
 		// Loops
 		"loop 5 times": {
@@ -304,6 +319,11 @@ func TestConditionalsAndLoops(t *testing.T) {
 			expectedOutput: "0 2 4 6 ",
 			expectedStack:  []int{},
 		},
+		"nested loops": {
+			input:          ": t 3 0 do 2 0 do i loop loop ; t",
+			expectedOutput: "",
+			expectedStack:  []int{0, 1, 0, 1, 0, 1},
+		},
 		"use index (i) twice dupping": {
 			input:          ": ci 3 1 do cr i . i . loop ;\nci",
 			expectedOutput: "\n1 1 \n2 2 ",
@@ -332,6 +352,901 @@ func TestConditionalsAndLoops(t *testing.T) {
 	}
 }
 
+func TestLocals(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"arguments": {
+			input:          ": sub {: a b :} a b - ; 5 3 sub",
+			expectedOutput: "",
+			expectedStack:  []int{2},
+		},
+		"arguments reordered": {
+			input:          ": rsub {: a b -- c :} b a - ; 5 3 rsub",
+			expectedOutput: "",
+			expectedStack:  []int{-2},
+		},
+		"uninitialized and to": {
+			input:          ": sq {: a | tmp :} tmp . a a * to tmp tmp ; 4 sq",
+			expectedOutput: "0 ",
+			expectedStack:  []int{16},
+		},
+		"locals shadow words": {
+			input:          ": shadow {: dup :} dup dup ; 7 shadow",
+			expectedOutput: "",
+			expectedStack:  []int{7, 7},
+		},
+		"scoped to the definition": {
+			input:          ": inner x ; : outer {: x :} inner ; 1 outer",
+			expectedOutput: "x ?\n",
+			expectedStack:  []int{},
+		},
+		"recursion": {
+			input:          ": fact {: n :} n 1 = if 1 else n 1 - fact n * then ; 5 fact",
+			expectedOutput: "",
+			expectedStack:  []int{120},
+		},
+		"outside a definition": {
+			input:          "{: a :}",
+			expectedOutput: "locals can only be declared inside a definition\n",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestDataSpaceAndStructures(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"variable": {
+			input:          "variable x 42 x ! x @ 1 x +! x @",
+			expectedOutput: "",
+			expectedStack:  []int{42, 43},
+		},
+		"constant": {
+			input:          "10 constant ten ten ten +",
+			expectedOutput: "",
+			expectedStack:  []int{20},
+		},
+		"create and comma": {
+			input:          "create data 1 , 2 , 3 , data 2 cells + @ data @",
+			expectedOutput: "",
+			expectedStack:  []int{3, 1},
+		},
+		"characters": {
+			input:          "create s 72 c, 105 c, s c@ s char+ c@ here s -",
+			expectedOutput: "",
+			expectedStack:  []int{72, 105, 2},
+		},
+		"allot": {
+			input:          "here 3 cells allot here swap -",
+			expectedOutput: "",
+			expectedStack:  []int{24},
+		},
+		"invalid address": {
+			input:          "-1 @",
+			expectedOutput: "invalid memory address -1\n",
+			expectedStack:  []int{},
+		},
+		"structure": {
+			input: "begin-structure point field: p.x field: p.y end-structure " +
+				"create p point allot 3 p p.x ! 4 p p.y ! p p.x @ p p.y @ point",
+			expectedOutput: "",
+			expectedStack:  []int{3, 4, 16},
+		},
+		"structure offsets": {
+			input: "begin-structure rec cfield: r.flag field: r.count 3 +field r.tag ffield: r.value end-structure " +
+				"0 r.flag 0 r.count 0 r.tag 0 r.value rec",
+			expectedOutput: "",
+			expectedStack:  []int{0, 8, 16, 24, 32},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestExecutionTokens(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"tick and execute": {
+			input:          "2 3 ' + execute",
+			expectedOutput: "",
+			expectedStack:  []int{5},
+		},
+		"execute a definition": {
+			input:          ": double 2 * ; 4 ' double execute",
+			expectedOutput: "",
+			expectedStack:  []int{8},
+		},
+		"bracket tick in a definition": {
+			input:          ": apply ['] . execute ; 7 apply",
+			expectedOutput: "7 ",
+			expectedStack:  []int{},
+		},
+		"higher order word": {
+			input:          ": map3 {: a b c xt :} a xt execute b xt execute c xt execute ; 1 2 3 ' dup map3",
+			expectedOutput: "",
+			expectedStack:  []int{1, 1, 2, 2, 3, 3},
+		},
+		"unknown word": {
+			input:          "' nothing",
+			expectedOutput: "nothing ?\n",
+			expectedStack:  []int{},
+		},
+		"invalid execution token": {
+			input:          "-1 execute",
+			expectedOutput: "invalid execution token -1\n",
+			expectedStack:  []int{},
+		},
+		"defer and is": {
+			input:          "defer greet : hi .\" hi\" ; ' hi is greet greet",
+			expectedOutput: "hi",
+			expectedStack:  []int{},
+		},
+		"late binding": {
+			input:          "defer op : calc 2 3 op ; ' + is op calc ' * is op calc",
+			expectedOutput: "",
+			expectedStack:  []int{5, 6},
+		},
+		"action-of": {
+			input:          "defer op ' swap is op action-of op ' swap =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+		},
+		"uninitialized deferred word": {
+			input:          "defer op op",
+			expectedOutput: "op is an uninitialized deferred word\n",
+			expectedStack:  []int{},
+		},
+		"is on a word that is not deferred": {
+			input:          "' + is dup drop",
+			expectedOutput: "dup is not a deferred word\n",
+			expectedStack:  []int{},
+		},
+		">body": {
+			input:          "create data 5 , ' data >body @",
+			expectedOutput: "",
+			expectedStack:  []int{5},
+		},
+		">body of a variable": {
+			input:          "variable v 7 v ! ' v >body @",
+			expectedOutput: "",
+			expectedStack:  []int{7},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestQuotations(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"execute a quotation": {
+			input:          "3 [: dup * ;] execute",
+			expectedOutput: "",
+			expectedStack:  []int{9},
+		},
+		"pass a quotation to a word": {
+			input:          ": times {: xt n :} n 0 do xt execute loop ; [: .\" hi\" ;] 3 times",
+			expectedOutput: "hihihi",
+			expectedStack:  []int{},
+		},
+		"quotation in a definition": {
+			input:          ": squares 4 1 do i [: dup * . ;] execute loop ; squares",
+			expectedOutput: "1 4 9 ",
+			expectedStack:  []int{},
+		},
+		"quotation in a definition is created once": {
+			input:          ": q [: 1 ;] ; q q =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+		},
+		"nested quotations": {
+			input:          "[: [: 2 ;] execute 3 + ;] execute",
+			expectedOutput: "",
+			expectedStack:  []int{5},
+		},
+		"quotations are not in the dictionary": {
+			input:          "[: 1 ;] drop ;]",
+			expectedOutput: ";] ?\n",
+			expectedStack:  []int{},
+		},
+		"missing end": {
+			input:          "[: 1",
+			expectedOutput: "missing ';]'\n",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"s\" and type": {
+			input:          "s\" hello world\" type",
+			expectedOutput: "hello world",
+			expectedStack:  []int{},
+		},
+		"s\" length": {
+			input:          "s\" hello\" swap drop",
+			expectedOutput: "",
+			expectedStack:  []int{5},
+		},
+		"s\\\" and escapes": {
+			input:          "s\\\" a\\tb\\n\\\"c\\\"\\x41\\\\\" type",
+			expectedOutput: "a\tb\n\"c\"A\\",
+			expectedStack:  []int{},
+		},
+		"s\\\" in a definition": {
+			input:          ": greeting s\\\" hi\\q\" ; greeting type",
+			expectedOutput: "hi\"",
+			expectedStack:  []int{},
+		},
+		"s\\\" invalid escape": {
+			input:          "s\\\" \\y\"",
+			expectedOutput: "invalid escape \\y\n",
+			expectedStack:  []int{},
+		},
+		"c\" and count": {
+			input:          "c\" abc\" count type",
+			expectedOutput: "abc",
+			expectedStack:  []int{},
+		},
+		"literals in definitions are stored once": {
+			input:          ": greeting s\" hi\" ; greeting drop greeting drop =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+		},
+		"identical literals are stored apart": {
+			input:          "s\" abc\" type s\" abc\" drop 65 swap c! s\" abc\" type",
+			expectedOutput: "abcabc",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestParsing(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"comment ending mid word": {
+			input:          "1 ( a comment) 2",
+			expectedOutput: "",
+			expectedStack:  []int{1, 2},
+		},
+		"comment over several lines": {
+			input:          "1 ( a\ncomment ) 2",
+			expectedOutput: "",
+			expectedStack:  []int{1, 2},
+		},
+		".\" keeps spaces": {
+			input:          ".\" hello  world\"",
+			expectedOutput: "hello  world",
+			expectedStack:  []int{},
+		},
+		".\" in a definition keeps spaces": {
+			input:          ": hi .\" a  b\" ; hi",
+			expectedOutput: "a  b",
+			expectedStack:  []int{},
+		},
+		"s\" ending mid word": {
+			input:          "s\" a  b\"type",
+			expectedOutput: "a  b",
+			expectedStack:  []int{},
+		},
+		"definition over several lines": {
+			input:          ": add\n  + ;\n1 2 add",
+			expectedOutput: "",
+			expectedStack:  []int{3},
+		},
+		"char and [char]": {
+			input:          "char A char hello : x [char] x ; x",
+			expectedOutput: "",
+			expectedStack:  []int{65, 104, 120},
+		},
+		"; in strings in a definition": {
+			input:          ": t .\" a ; b\" s\" ;\" type s\\\" \\\";\" type c\" ;\" count type [char] ; emit ; t",
+			expectedOutput: "a ; b;\";;;",
+			expectedStack:  []int{},
+		},
+		"words ending a quotation or loop in strings": {
+			input:          ": t [: .\" ;]\" ;] execute 2 0 do .\" loop\" loop ; t",
+			expectedOutput: ";]looploop",
+			expectedStack:  []int{},
+		},
+		"char parses the input source": {
+			input:          ": c char ; c Z",
+			expectedOutput: "",
+			expectedStack:  []int{90},
+		},
+		"parse": {
+			input:          "char ) parse hello world) type",
+			expectedOutput: "hello world",
+			expectedStack:  []int{},
+		},
+		"parse-name": {
+			input:          "parse-name   foo type",
+			expectedOutput: "foo",
+			expectedStack:  []int{},
+		},
+		"word": {
+			input:          "char , word ,,abc, count type",
+			expectedOutput: "abc",
+			expectedStack:  []int{},
+		},
+		"source": {
+			input:          "source type",
+			expectedOutput: "source type",
+			expectedStack:  []int{},
+		},
+		">in": {
+			input:          ">in @",
+			expectedOutput: "",
+			expectedStack:  []int{5},
+		},
+		"skipping the rest of the line with >in": {
+			input:          "1 source swap drop >in ! 2\n3",
+			expectedOutput: "",
+			expectedStack:  []int{1, 3},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		keyboard       string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"line comment": {
+			input:          "1 \\ 2 3\n4",
+			expectedOutput: "",
+			expectedStack:  []int{1, 4},
+		},
+		"line comment in a definition": {
+			input:          ": f 1 \\ 2 ;\n3 ;\nf",
+			expectedOutput: "",
+			expectedStack:  []int{1, 3},
+		},
+		"printing comment": {
+			input:          ".( hello  world) 1",
+			expectedOutput: "hello  world",
+			expectedStack:  []int{1},
+		},
+		"comments don't nest": {
+			input:          "1 ( see (a ) 2",
+			expectedOutput: "",
+			expectedStack:  []int{1, 2},
+		},
+		"comment containing ;": {
+			input:          ": f ( ; ) 1 ; f",
+			expectedOutput: "",
+			expectedStack:  []int{1},
+		},
+		"comment continuing on the next line of input": {
+			input:          "1 ( a",
+			keyboard:       "b ) 2\n",
+			expectedOutput: "",
+			expectedStack:  []int{1, 2},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input,
+				WithInput(strings.NewReader(test.keyboard)),
+				WithContinuationPrompt(func() {}))
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestDictionaryIntrospection(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"words newest first": {
+			input:          ": first ; : second ; words",
+			expectedOutput: "second first ",
+			expectedStack:  []int{},
+		},
+		"words-like": {
+			input:          ": my-one ; : other ; : my-two ; words-like my-",
+			expectedOutput: "my-two my-one\n",
+			expectedStack:  []int{},
+		},
+		"redefined words are listed once": {
+			input:          "vocabulary v also v definitions : a-word 1 ; : a-word 2 ; words",
+			expectedOutput: "redefined a-word\na-word\n",
+			expectedStack:  []int{},
+		},
+		"see a definition": {
+			input:          ": fib ( n1 n2 -- n1 n2 n3 ) over over + ; see fib",
+			expectedOutput: ": fib ( n1 n2 -- n1 n2 n3 ) over over + ;\n",
+			expectedStack:  []int{},
+		},
+		"see an immediate definition": {
+			input:          ": now 1 ; immediate see now",
+			expectedOutput: ": now 1 ; immediate\n",
+			expectedStack:  []int{},
+		},
+		"see a primitive": {
+			input:          "see +",
+			expectedOutput: "+ ( n1 n2 -- sum ) is a primitive\n",
+			expectedStack:  []int{},
+		},
+		"see an unknown word": {
+			input:          "see nothing",
+			expectedOutput: "nothing ?\n",
+			expectedStack:  []int{},
+		},
+		"find": {
+			input:          "c\" dup\" find swap ' dup =",
+			expectedOutput: "",
+			expectedStack:  []int{-1, -1},
+		},
+		"find an immediate word": {
+			input:          "c\" if\" find swap drop",
+			expectedOutput: "",
+			expectedStack:  []int{1},
+		},
+		"find an unknown word": {
+			input:          "c\" nothing\" dup find rot rot =",
+			expectedOutput: "",
+			expectedStack:  []int{0, -1},
+		},
+		"search-wordlist": {
+			input:          ": double 2 * ; s\" double\" forth-wordlist search-wordlist drop 4 swap execute",
+			expectedOutput: "",
+			expectedStack:  []int{8},
+		},
+		"search-wordlist for an unknown word": {
+			input:          "s\" nothing\" forth-wordlist search-wordlist",
+			expectedOutput: "",
+			expectedStack:  []int{0},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if !strings.HasPrefix(o.String(), test.expectedOutput) {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestDefinitionLocation(t *testing.T) {
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, "1 2\n\n: third 3 ;\nsee third")
+	interpreter.SetSourceName("test.forth")
+	for {
+		w, err := interpreter.Word()
+		if err != nil {
+			break
+		}
+		interpreter.Interpret(w)
+	}
+
+	expected := "( test.forth:3 )\n: third 3 ;\n"
+	if o.String() != expected {
+		t.Errorf("expected '%v', got '%v'", expected, o.String())
+	}
+}
+
+func TestWordlists(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"define into a new wordlist": {
+			input:          "wordlist constant lib lib set-current : helper 42 ; forth-wordlist set-current helper",
+			expectedOutput: "helper ?\n",
+			expectedStack:  []int{},
+		},
+		"search a new wordlist": {
+			input: "wordlist constant lib lib set-current : helper 42 ; forth-wordlist set-current " +
+				"get-order lib swap 1 + set-order helper",
+			expectedOutput: "",
+			expectedStack:  []int{42},
+		},
+		"get-order": {
+			input:          "get-order",
+			expectedOutput: "",
+			expectedStack:  []int{0, 0, 2},
+		},
+		"vocabulary": {
+			input:          "vocabulary lib also lib definitions : + * ; 3 4 + previous definitions 3 4 +",
+			expectedOutput: "",
+			expectedStack:  []int{12, 7},
+		},
+		"only": {
+			input:          "vocabulary lib also lib also lib only get-order",
+			expectedOutput: "",
+			expectedStack:  []int{0, 0, 2},
+		},
+		"order": {
+			input:          "vocabulary lib lib definitions order",
+			expectedOutput: "lib forth current: lib\n",
+			expectedStack:  []int{},
+		},
+		"a vocabulary is searched before forth": {
+			input:          "vocabulary x x definitions : five 5 ; 1 2 + five forth five",
+			expectedOutput: "five ?\n",
+			expectedStack:  []int{3, 5},
+		},
+		"get-current": {
+			input:          "wordlist dup set-current get-current =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+		},
+		"search-wordlist": {
+			input: "wordlist constant lib lib set-current : helper 42 ; forth-wordlist set-current " +
+				"s\" helper\" lib search-wordlist swap drop s\" helper\" forth-wordlist search-wordlist",
+			expectedOutput: "",
+			expectedStack:  []int{-1, 0},
+		},
+		"invalid wordlist": {
+			input:          "99 set-current",
+			expectedOutput: "invalid wordlist 99\n",
+			expectedStack:  []int{99},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestMarkerAndForget(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"marker removes later definitions": {
+			input:          "marker clean : double 2 * ; 3 double clean 3 double",
+			expectedOutput: "double ?\n",
+			expectedStack:  []int{6, 3},
+		},
+		"marker removes itself": {
+			input:          "marker clean clean clean",
+			expectedOutput: "clean ?\n",
+			expectedStack:  []int{},
+		},
+		"marker restores redefined words": {
+			input:          ": value 1 ; marker clean : value 2 ; value clean value",
+			expectedOutput: "redefined value\n",
+			expectedStack:  []int{2, 1},
+		},
+		"marker restores data space": {
+			input:          "here marker clean variable x 10 allot clean here =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+		},
+		"marker restores the contents of data space": {
+			input:          "variable v 5 v ! marker m 7 v ! m v @",
+			expectedOutput: "",
+			expectedStack:  []int{5},
+		},
+		"marker restores the search order": {
+			input:          "marker clean vocabulary lib also lib definitions clean order",
+			expectedOutput: "forth forth current: forth\n",
+			expectedStack:  []int{},
+		},
+		"marker resets deferred words": {
+			input:          "defer op marker clean : add + ; ' add is op clean 1 2 op",
+			expectedOutput: "op is an uninitialized deferred word\n",
+			expectedStack:  []int{1, 2},
+		},
+		"forget": {
+			input:          ": one 1 ; : two 2 ; : three 3 ; forget two one two three",
+			expectedOutput: "two ?\nthree ?\n",
+			expectedStack:  []int{1},
+		},
+		"forget restores data space": {
+			input:          "here variable x 5 x ! forget x here =",
+			expectedOutput: "",
+			expectedStack:  []int{-1},
+		},
+		"forget a built-in word": {
+			input:          "forget dup 1 dup",
+			expectedOutput: "cannot forget built-in word dup\n",
+			expectedStack:  []int{1, 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
+func TestRedefinition(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		protect        bool
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"redefining a word warns": {
+			input:          ": + * ; 2 3 +",
+			expectedOutput: "redefined +\n",
+			expectedStack:  []int{6},
+		},
+		"defining a word in another wordlist doesn't warn": {
+			input:          "vocabulary lib also lib definitions : + * ; 2 3 +",
+			expectedOutput: "",
+			expectedStack:  []int{6},
+		},
+		"existing definitions call the old word": {
+			input:          ": sum + ; : + * ; 2 3 sum 2 3 +",
+			expectedOutput: "redefined +\n",
+			expectedStack:  []int{5, 6},
+		},
+		"existing definitions call the old definition": {
+			input:          ": one 1 ; : two one one + ; : one 10 ; two",
+			expectedOutput: "redefined one\n",
+			expectedStack:  []int{2},
+		},
+		"quotations call the old word": {
+			input:          ": one 1 ; : q [: one ;] execute ; : one 10 ; q",
+			expectedOutput: "redefined one\n",
+			expectedStack:  []int{1},
+		},
+		"identical quotations call the word they were written after": {
+			input:          ": foo 1 ; : a [: foo ;] execute ; : foo 2 ; : b [: foo ;] execute ; a . b .",
+			expectedOutput: "redefined foo\n1 2 ",
+			expectedStack:  []int{},
+		},
+		"tick in a definition finds the old word": {
+			input:          ": one 1 ; : q ['] one execute ; : one 10 ; q",
+			expectedOutput: "redefined one\n",
+			expectedStack:  []int{1},
+		},
+		"recurse": {
+			input:          ": fact dup 1 > if dup 1 - recurse * then ; 5 fact",
+			expectedOutput: "",
+			expectedStack:  []int{120},
+		},
+		"redefining a recursive word": {
+			input:          ": count-down dup . dup 0 > if 1 - recurse then ; : count-down 1 + count-down ; 1 count-down",
+			expectedOutput: "redefined count-down\n2 1 0 ",
+			expectedStack:  []int{0},
+		},
+		"protected built-ins": {
+			input:          ": + * ; 2 3 +",
+			protect:        true,
+			expectedOutput: "cannot redefine built-in word +\n",
+			expectedStack:  []int{5},
+		},
+		"protected built-ins can be shadowed in another wordlist": {
+			input:          "vocabulary lib also lib definitions : + * ; 2 3 +",
+			protect:        true,
+			expectedOutput: "",
+			expectedStack:  []int{6},
+		},
+		"protected built-ins don't stop user words being redefined": {
+			input:          ": one 1 ; : one 10 ; one",
+			protect:        true,
+			expectedOutput: "redefined one\n",
+			expectedStack:  []int{10},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input)
+			interpreter.ProtectBuiltins(test.protect)
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
+
 func ValidateStack(t *testing.T, stack Stack[int], expected []int) {
 	t.Helper()
 
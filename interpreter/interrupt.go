@@ -0,0 +1,43 @@
+package interpreter
+
+import "errors"
+
+// errInterrupted is panicked with to unwind the words being run when they are
+// interrupted, it's printed when the outermost word recovers from it.
+var errInterrupted = interruption("interrupted\n")
+
+// errBye is panicked with by bye to unwind the words being run, after which
+// the interpreter reads no more words.
+var errBye = interruption("bye")
+
+// ErrBye is returned by Word and Include once bye has been run, so the
+// program running the interpreter can finish what it's doing, like writing
+// reports, and exit.
+var ErrBye = errors.New("bye")
+
+type interruption string
+
+// Interrupt stops the words being run before the next word is run, returning
+// to the line being interpreted when the outermost one started and skipping
+// the rest of it. It can be called from another goroutine, such as one
+// handling SIGINT.
+func (i *Interpreter) Interrupt() {
+	i.interrupted.Store(true)
+}
+
+// unwind abandons the words being run after an interrupt, emptying the stacks
+// and returning to the given number of environments and files being
+// included.
+func (i *Interpreter) unwind(environments int, including int) {
+	i.environments = i.environments[:environments]
+	i.including = i.including[:including]
+	if s := i.inputSource(); s != nil {
+		i.activate(s)
+		for s.nextLine() {
+		}
+	}
+	i.stack = Stack[int]{}
+	i.loopStack = Stack[int]{}
+	i.frames = Stack[*frame]{}
+	i.structures = Stack[*int]{}
+}
@@ -0,0 +1,70 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterrupt(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		keyboard       string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"infinite recursion": {
+			input:          "1 : forever 2 stop forever ; forever 3",
+			keyboard:       "4\n",
+			expectedOutput: "interrupted\n",
+			expectedStack:  []int{4},
+		},
+		"loop": {
+			input:          ": counter 1000000 0 do i stop loop ; counter",
+			keyboard:       "5 ( a\ncomment ) 6\n",
+			expectedOutput: "interrupted\n",
+			expectedStack:  []int{5, 6},
+		},
+		"included file": {
+			input:          "s\" ./stop.forth\" included 3",
+			keyboard:       "\n",
+			expectedOutput: "interrupted\n",
+			expectedStack:  []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(writeFiles(t, map[string]string{"stop.forth": "1\n2 stop 3\n4"}))
+
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, test.input,
+				WithInput(strings.NewReader(test.keyboard)),
+				WithContinuationPrompt(func() {}))
+			interpreter.define(&ExecutableToken{
+				name: "stop",
+				primitive: func() {
+					interpreter.Interrupt()
+				},
+			})
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					if interpreter.Refill() != nil {
+						break
+					}
+					continue
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+			if len(interpreter.environments) != 1 || len(interpreter.including) != 0 || len(interpreter.frames.items) != 0 {
+				t.Errorf("expected the interpreter to be back at the input, got %d environments", len(interpreter.environments))
+			}
+		})
+	}
+}
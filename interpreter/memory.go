@@ -0,0 +1,87 @@
+package interpreter
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// CellSize is the number of bytes used to store a cell in data space.
+const CellSize = 8
+
+// Memory is the data space, a contiguous block of bytes addressed from zero
+// that grows as space is allotted.
+type Memory struct {
+	bytes []byte
+}
+
+func (m *Memory) Here() int {
+	return len(m.bytes)
+}
+
+func (m *Memory) Allot(n int) error {
+	if len(m.bytes)+n < 0 {
+		return fmt.Errorf("data space underflow")
+	}
+	if n < 0 {
+		m.bytes = m.bytes[:len(m.bytes)+n]
+	} else {
+		m.bytes = append(m.bytes, make([]byte, n)...)
+	}
+	return nil
+}
+
+func (m *Memory) Align() {
+	_ = m.Allot(Aligned(m.Here()) - m.Here())
+}
+
+func (m *Memory) Fetch(addr int) (int, error) {
+	if addr < 0 || addr+CellSize > len(m.bytes) {
+		return 0, fmt.Errorf("invalid memory address %d", addr)
+	}
+	return int(binary.LittleEndian.Uint64(m.bytes[addr:])), nil
+}
+
+func (m *Memory) Store(addr int, v int) error {
+	if addr < 0 || addr+CellSize > len(m.bytes) {
+		return fmt.Errorf("invalid memory address %d", addr)
+	}
+	binary.LittleEndian.PutUint64(m.bytes[addr:], uint64(v))
+	return nil
+}
+
+func (m *Memory) FetchByte(addr int) (int, error) {
+	if addr < 0 || addr >= len(m.bytes) {
+		return 0, fmt.Errorf("invalid memory address %d", addr)
+	}
+	return int(m.bytes[addr]), nil
+}
+
+func (m *Memory) StoreByte(addr int, v int) error {
+	if addr < 0 || addr >= len(m.bytes) {
+		return fmt.Errorf("invalid memory address %d", addr)
+	}
+	m.bytes[addr] = byte(v)
+	return nil
+}
+
+// Aligned rounds addr up to the next cell boundary.
+func Aligned(addr int) int {
+	return (addr + CellSize - 1) / CellSize * CellSize
+}
+
+// Read returns a copy of the n bytes starting at addr.
+func (m *Memory) Read(addr int, n int) ([]byte, error) {
+	if addr < 0 || n < 0 || addr+n > len(m.bytes) {
+		return nil, fmt.Errorf("invalid memory address %d", addr)
+	}
+	return append([]byte{}, m.bytes[addr:addr+n]...), nil
+}
+
+// Write copies b into memory starting at addr.
+func (m *Memory) Write(addr int, b []byte) error {
+	if addr < 0 || addr+len(b) > len(m.bytes) {
+		return fmt.Errorf("invalid memory address %d", addr)
+	}
+	copy(m.bytes[addr:], b)
+	return nil
+}
@@ -0,0 +1,89 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestMemoryAllot(t *testing.T) {
+	memory := Memory{}
+
+	if err := memory.Allot(10); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if memory.Here() != 10 {
+		t.Errorf("expected here to be 10, got %d", memory.Here())
+	}
+
+	memory.Align()
+	if memory.Here() != 16 {
+		t.Errorf("expected here to be 16, got %d", memory.Here())
+	}
+
+	if err := memory.Allot(-16); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if memory.Here() != 0 {
+		t.Errorf("expected here to be 0, got %d", memory.Here())
+	}
+
+	if err := memory.Allot(-1); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestMemoryFetchAndStore(t *testing.T) {
+	memory := Memory{}
+	_ = memory.Allot(2 * CellSize)
+
+	if err := memory.Store(CellSize, -42); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	v, err := memory.Fetch(CellSize)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if v != -42 {
+		t.Errorf("expected -42, got %d", v)
+	}
+
+	if err := memory.StoreByte(1, 65); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	v, err = memory.FetchByte(1)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if v != 65 {
+		t.Errorf("expected 65, got %d", v)
+	}
+
+	if _, err := memory.Fetch(CellSize + 1); err == nil {
+		t.Errorf("expected an error")
+	}
+	if err := memory.StoreByte(-1, 0); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestMemoryReadAndWrite(t *testing.T) {
+	memory := Memory{}
+	_ = memory.Allot(8)
+
+	if err := memory.Write(2, []byte("abc")); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	b, err := memory.Read(1, 4)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if string(b) != "\x00abc" {
+		t.Errorf("expected \"\\x00abc\", got %q", b)
+	}
+
+	if err := memory.Write(6, []byte("abc")); err == nil {
+		t.Errorf("expected an error")
+	}
+	if _, err := memory.Read(6, 3); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,315 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// inputBufferSize is the longest line of an input source that can be seen
+// from Forth code.
+const inputBufferSize = BlockSize
+
+// wordBufferSize is the size of the buffer word stores the counted string it
+// returns in.
+const wordBufferSize = 256
+
+// reserveInputBuffers reserves data space for >in, the input buffer and the
+// buffer used by word.
+func (i *Interpreter) reserveInputBuffers() {
+	i.toIn = i.memory.Here()
+	_ = i.memory.Allot(CellSize)
+	i.inputBuffer = i.memory.Here()
+	_ = i.memory.Allot(inputBufferSize)
+	i.wordBuffer = i.memory.Here()
+	_ = i.memory.Allot(wordBufferSize)
+	i.memory.Align()
+}
+
+// inputSource returns the environment being interpreted, ignoring the
+// definitions it is running.
+func (i *Interpreter) inputSource() *environment {
+	for n := len(i.environments) - 1; n >= 0; n-- {
+		if i.environments[n].source {
+			return i.environments[n]
+		}
+	}
+	return nil
+}
+
+// activate makes the position of e the one >in refers to.
+func (i *Interpreter) activate(e *environment) {
+	e.memory = &i.memory
+	e.addr = i.toIn
+	e.setPosition(e.in)
+}
+
+// pushSource makes e the input source, keeping the position of the source it
+// interrupts.
+func (i *Interpreter) pushSource(e *environment) {
+	if s := i.inputSource(); s != nil {
+		s.in = s.position()
+		s.addr = -1
+	}
+	e.source = true
+	i.environments = append(i.environments, e)
+	i.activate(e)
+}
+
+// popSource returns to the input source interrupted by the last one pushed.
+func (i *Interpreter) popSource() {
+	i.environments = i.environments[:len(i.environments)-1]
+	if s := i.inputSource(); s != nil {
+		i.activate(s)
+	}
+}
+
+// sourceLine copies the current line of the input source to the input buffer,
+// returning the input source.
+func (i *Interpreter) sourceLine() *environment {
+	s := i.inputSource()
+	line := s.current()
+	if len(line) > inputBufferSize {
+		panic("input line too long")
+	}
+	if err := i.memory.Write(i.inputBuffer, []byte(line)); err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+// collect reads words from the environment at the top of the stack up to the
+// one done returns true for, returning the text before it and whether it was
+// found. The text read by immediate parsing words, like comments and strings,
+// may contain the word being looked for, so it's skipped; other parsing words
+// read their text when the definition runs.
+func (i *Interpreter) collect(done func(string) bool) (string, bool) {
+	e := i.environments[len(i.environments)-1]
+	n, start := e.n, e.position()
+	for e.Scan() {
+		if done(e.Text()) {
+			return e.text(n, start, e.n, e.start), true
+		}
+		end := parsingWords[e.Text()]
+		if word, ok := i.lookup(e.Text()); !ok || !word.immediate {
+			end = ""
+		}
+		switch end {
+		case "", ":}":
+		case " ":
+			e.Scan()
+		case ")":
+			i.skipComment(e)
+		case "\n":
+			e.setPosition(len(e.current()))
+		case "\\\"":
+			e.skipEscaped()
+		default:
+			e.parse(end[0])
+		}
+	}
+	return e.text(n, start, e.n, len(e.current())), false
+}
+
+// parseString reads the text up to the next quote in the line being
+// interpreted.
+func (i *Interpreter) parseString() string {
+	e := i.environments[len(i.environments)-1]
+	start, end, found := e.parse('"')
+	if !found {
+		panic("missing '\"'")
+	}
+	return e.current()[start:end]
+}
+
+// escapes are the characters written after a backslash in the strings read
+// by s\", mapped to the text they stand for.
+var escapes = map[byte]string{
+	'a': "\a", 'b': "\b", 'e': "\x1b", 'f': "\f", 'l': "\n", 'm': "\r\n", 'n': "\n",
+	'q': "\"", 'r': "\r", 't': "\t", 'v': "\v", 'z': "\x00", '"': "\"", '\\': "\\",
+}
+
+// parseEscapedString reads the text up to the next quote in the line being
+// interpreted, replacing the escapes in it, a backslash followed by x and two
+// hex digits standing for that character.
+func (i *Interpreter) parseEscapedString() string {
+	e := i.environments[len(i.environments)-1]
+	line := e.current()
+	var s strings.Builder
+	for p := e.position(); p < len(line); p++ {
+		switch {
+		case line[p] == '"':
+			e.setPosition(p + 1)
+			return s.String()
+		case line[p] != '\\' || p+1 == len(line):
+			s.WriteByte(line[p])
+		case line[p+1] == 'x':
+			n, err := strconv.ParseUint(line[p+2:min(p+4, len(line))], 16, 8)
+			if err != nil || p+4 > len(line) {
+				e.setPosition(len(line))
+				panic("invalid escape \\x\n")
+			}
+			s.WriteByte(byte(n))
+			p += 3
+		default:
+			escape, ok := escapes[line[p+1]]
+			if !ok {
+				e.setPosition(len(line))
+				panic(fmt.Sprintf("invalid escape \\%c\n", line[p+1]))
+			}
+			s.WriteString(escape)
+			p++
+		}
+	}
+	e.setPosition(len(line))
+	panic("missing '\"'")
+}
+
+// skipComment skips the text up to the ')' ending a comment. Comments don't
+// nest, a '(' in a comment is just text, but they can continue over several
+// lines of a file, or of the user input device where the lines are read as
+// they are needed.
+func (i *Interpreter) skipComment(e *environment) {
+	for {
+		line := e.current()
+		if p := strings.IndexByte(line[e.position():], ')'); p >= 0 {
+			e.setPosition(e.position() + p + 1)
+			return
+		}
+		e.setPosition(len(line))
+		if !e.advance() {
+			return
+		}
+	}
+}
+
+// sourceStart returns the line and column the first word of text collected
+// from the given line and column is at, so the words of a definition can be
+// found in its source.
+func sourceStart(text string, line int, column int) (int, int) {
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \t\r\n"))]
+	if n := strings.LastIndex(leading, "\n"); n != -1 {
+		return line + strings.Count(leading, "\n"), len(leading) - n - 1
+	}
+	return line, column + len(leading)
+}
+
+// parsingWords are the words that read the text following them, mapped to
+// the text they read up to; a space for the next word, and a backslash before
+// the delimiter where backslashes escape the character after them.
+var parsingWords = map[string]string{
+	"(": ")", "\\": "\n", ".(": ")", ".\"": "\"", "s\"": "\"", "s\\\"": "\\\"", "c\"": "\"",
+	"[char]": " ", "char": " ", "'": " ", "[']": " ", "to": " ",
+	"is": " ", "action-of": " ", "constant": " ", "variable": " ", "create": " ",
+	"defer": " ", "{:": ":}", ":": " ", "include": " ", "require": " ",
+	"marker": " ", "forget": " ", "vocabulary": " ", "see": " ", "debug": " ",
+	"break": " ", "unbreak": " ", "trace": " ", "trace-only": " ",
+	"words-like": " ", "begin-structure": " ", "+field": " ", "field:": " ",
+	"cfield:": " ", "ffield:": " ",
+}
+
+// structureWords end control structures, they are read by the words that
+// start them rather than run.
+var structureWords = map[string]bool{"then": true, "else": true, "loop": true, ";]": true}
+
+// position is where a word is written in a file, column counts from 0.
+type position struct {
+	file   string
+	line   int
+	column int
+}
+
+func (p position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.file, p.line, p.column+1)
+}
+
+// sourceToken is a word in some source and where it is, arg is the text read
+// by a parsing word.
+type sourceToken struct {
+	text string
+	arg  string
+	position
+}
+
+// sourceTokens splits source starting at the given line and column into the
+// words the interpreter would read from it, without running them, so the text
+// read by parsing words is the arg of the parsing word rather than a word.
+func sourceTokens(source string, file string, line int, column int) []sourceToken {
+	lines := strings.Split(source, "\n")
+	for n, l := range lines {
+		lines[n] = strings.TrimSuffix(l, "\r")
+	}
+	n, p := 0, 0
+	// next returns the next word, moving on to the following lines
+	next := func() (sourceToken, bool) {
+		for n < len(lines) {
+			l := lines[n]
+			for p < len(l) && isSpace(l[p]) {
+				p++
+			}
+			if p < len(l) {
+				start := p
+				for p < len(l) && !isSpace(l[p]) {
+					p++
+				}
+				c := start
+				if n == 0 {
+					c += column
+				}
+				return sourceToken{text: l[start:p], position: position{file: file, line: line + n, column: c}}, true
+			}
+			n, p = n+1, 0
+		}
+		return sourceToken{}, false
+	}
+	// parse returns the text up to the delimiter, moving past it, comments
+	// can continue over several lines
+	parse := func(delimiter byte, escaped bool) string {
+		if p < len(lines[n]) {
+			p++
+		}
+		var text strings.Builder
+		for ; n < len(lines); n, p = n+1, 0 {
+			if text.Len() > 0 {
+				text.WriteString("\n")
+			}
+			for ; p < len(lines[n]); p++ {
+				if escaped && lines[n][p] == '\\' && p+1 < len(lines[n]) {
+					text.WriteByte(lines[n][p])
+					p++
+				} else if lines[n][p] == delimiter {
+					p++
+					return text.String()
+				}
+				text.WriteByte(lines[n][p])
+			}
+			if delimiter == '\n' || delimiter == '"' {
+				return text.String()
+			}
+		}
+		return text.String()
+	}
+
+	var tokens []sourceToken
+	for {
+		t, ok := next()
+		if !ok {
+			return tokens
+		}
+		switch end := parsingWords[t.text]; end {
+		case "":
+		case " ":
+			arg, _ := next()
+			t.arg = arg.text
+		case ":}":
+			var args []string
+			for arg, ok := next(); ok && arg.text != ":}"; arg, ok = next() {
+				args = append(args, arg.text)
+			}
+			t.arg = strings.Join(args, " ")
+		default:
+			t.arg = parse(end[len(end)-1], strings.HasPrefix(end, "\\"))
+		}
+		tokens = append(tokens, t)
+	}
+}
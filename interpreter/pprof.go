@@ -0,0 +1,157 @@
+package interpreter
+
+import (
+	"compress/gzip"
+	"io"
+	"slices"
+)
+
+// WriteProfile writes the profile recorded in the gzipped protocol buffer
+// format read by go tool pprof. Each sample is a chain of calls, with the
+// number of calls to the last word in the chain and the time spent in it.
+func (i *Interpreter) WriteProfile(w io.Writer) error {
+	p := i.profile()
+
+	indexes := map[string]int{"": 0}
+	table := []string{""}
+	index := func(s string) int64 {
+		n, ok := indexes[s]
+		if !ok {
+			n = len(table)
+			indexes[s] = n
+			table = append(table, s)
+		}
+		return int64(n)
+	}
+
+	var profile protobuf
+	for _, sampleType := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}} {
+		profile.message(1, func(m *protobuf) {
+			m.int64(1, index(sampleType[0]))
+			m.int64(2, index(sampleType[1]))
+		})
+	}
+
+	// each word is a function with a single location, they have the same id
+	keys := make([]string, 0, len(p.stacks))
+	for key := range p.stacks {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	ids := make(map[*ExecutableToken]uint64)
+	var words []*ExecutableToken
+	for _, key := range keys {
+		s := p.stacks[key]
+		var locations []uint64
+		for _, word := range s.words {
+			if _, ok := ids[word]; !ok {
+				words = append(words, word)
+				ids[word] = uint64(len(words))
+			}
+			locations = append(locations, ids[word])
+		}
+		// the last word called comes first
+		slices.Reverse(locations)
+		profile.message(2, func(m *protobuf) {
+			m.packed(1, locations)
+			m.packed(2, []uint64{uint64(s.calls), uint64(s.time.Nanoseconds())})
+		})
+	}
+
+	for _, word := range words {
+		profile.message(4, func(m *protobuf) {
+			m.uint64(1, ids[word])
+			m.message(4, func(m *protobuf) {
+				m.uint64(1, ids[word])
+				m.int64(2, int64(word.line))
+			})
+		})
+	}
+	for _, word := range words {
+		profile.message(5, func(m *protobuf) {
+			m.uint64(1, ids[word])
+			m.int64(2, index(word.name))
+			m.int64(3, index(word.name))
+			m.int64(4, index(word.file))
+			m.int64(5, int64(word.line))
+		})
+	}
+
+	// the string table is written last, after every string has been added
+	for _, s := range table {
+		profile.string(6, s)
+	}
+	profile.int64(9, p.start.UnixNano())
+	profile.int64(10, p.now().Sub(p.start).Nanoseconds())
+
+	z := gzip.NewWriter(w)
+	if _, err := z.Write(profile.data); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+// protobuf encodes the fields of a protocol buffer message, as much of the
+// encoding as the pprof format needs.
+type protobuf struct {
+	data []byte
+}
+
+// The wire types of the fields.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protobuf) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// uint64 writes an integer field, leaving out zero, the default.
+func (b *protobuf) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protobuf) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *protobuf) bytes(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+// string writes a string field, including empty strings as they are needed
+// in repeated fields like the string table.
+func (b *protobuf) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+// packed writes a repeated integer field.
+func (b *protobuf) packed(field int, xs []uint64) {
+	var p protobuf
+	for _, x := range xs {
+		p.varint(x)
+	}
+	b.bytes(field, p.data)
+}
+
+// message writes a field holding the message written by fields.
+func (b *protobuf) message(field int, fields func(m *protobuf)) {
+	var m protobuf
+	fields(&m)
+	b.bytes(field, m.data)
+}
@@ -0,0 +1,169 @@
+package interpreter
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// profiler records how often each dictionary word is run and how long it
+// takes.
+type profiler struct {
+	hook  *ExecutionHook
+	now   func() time.Time
+	start time.Time
+
+	words map[*ExecutableToken]*wordProfile
+
+	// stacks records the calls and the time spent in the last word of each
+	// chain of calls, keyed by the chain, for the pprof profile
+	stacks map[string]*stackProfile
+
+	// calls are the words being run, the innermost last, with nil for the
+	// numbers and locals
+	calls []*profileCall
+}
+
+type wordProfile struct {
+	word      *ExecutableToken
+	calls     int
+	inclusive time.Duration
+	exclusive time.Duration
+
+	// active counts the calls being run, so the time of recursive calls is
+	// only included once
+	active int
+}
+
+type stackProfile struct {
+	words []*ExecutableToken
+	calls int
+	time  time.Duration
+}
+
+type profileCall struct {
+	word     *ExecutableToken
+	start    time.Time
+	children time.Duration
+}
+
+// profile returns the interpreter's profiler.
+func (i *Interpreter) profile() *profiler {
+	if i.profiler == nil {
+		p := &profiler{now: time.Now}
+		p.hook = &ExecutionHook{
+			Before: func(e Event) { i.profileBefore(p, e) },
+			After:  func(e Event) { i.profileAfter(p) },
+		}
+		p.reset()
+		i.profiler = p
+	}
+	return i.profiler
+}
+
+func (p *profiler) reset() {
+	p.start = p.now()
+	p.words = make(map[*ExecutableToken]*wordProfile)
+	p.stacks = make(map[string]*stackProfile)
+	p.calls = nil
+}
+
+// StartProfile discards any profile recorded and starts recording another.
+func (i *Interpreter) StartProfile() {
+	p := i.profile()
+	p.reset()
+	i.setHook(p.hook, true)
+}
+
+// StopProfile stops recording the profile, keeping what has been recorded.
+func (i *Interpreter) StopProfile() {
+	if i.profiler != nil {
+		i.setHook(i.profiler.hook, false)
+	}
+}
+
+func (i *Interpreter) profileBefore(p *profiler, e Event) {
+	call := &profileCall{}
+	if word, ok := i.eventWord(e); ok {
+		call.word = word
+		w, ok := p.words[word]
+		if !ok {
+			w = &wordProfile{word: word}
+			p.words[word] = w
+		}
+		w.calls++
+		w.active++
+	}
+	p.calls = append(p.calls, call)
+	call.start = p.now()
+}
+
+func (i *Interpreter) profileAfter(p *profiler) {
+	// profiling may have been started by the word
+	if len(p.calls) == 0 {
+		return
+	}
+	elapsed := p.now().Sub(p.calls[len(p.calls)-1].start)
+	call := p.calls[len(p.calls)-1]
+	p.calls = p.calls[:len(p.calls)-1]
+	if len(p.calls) > 0 {
+		p.calls[len(p.calls)-1].children += elapsed
+	}
+	if call.word == nil {
+		return
+	}
+
+	w := p.words[call.word]
+	w.active--
+	if w.active == 0 {
+		w.inclusive += elapsed
+	}
+	w.exclusive += elapsed - call.children
+
+	var words []*ExecutableToken
+	var key strings.Builder
+	for _, c := range append(p.calls, call) {
+		if c.word != nil {
+			words = append(words, c.word)
+			fmt.Fprintf(&key, "%d ", c.word.xt)
+		}
+	}
+	s, ok := p.stacks[key.String()]
+	if !ok {
+		s = &stackProfile{words: words}
+		p.stacks[key.String()] = s
+	}
+	s.calls++
+	s.time += elapsed - call.children
+}
+
+// profiled returns the words profiled, those taking longest first.
+func (p *profiler) profiled() []*wordProfile {
+	var words []*wordProfile
+	for _, w := range p.words {
+		words = append(words, w)
+	}
+	slices.SortFunc(words, func(a, b *wordProfile) int {
+		return cmp.Or(cmp.Compare(b.inclusive, a.inclusive), strings.Compare(a.word.name, b.word.name))
+	})
+	return words
+}
+
+// WriteProfileReport writes the number of calls and the time taken by each
+// word profiled, including and excluding the words it runs, the words taking
+// longest first.
+func (i *Interpreter) WriteProfileReport(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%-20s %10s %14s %14s\n", "word", "calls", "inclusive", "exclusive"); err != nil {
+		return err
+	}
+	for _, word := range i.profile().profiled() {
+		_, err := fmt.Fprintf(w, "%-20s %10d %14s %14s\n", word.word.name, word.calls, word.inclusive, word.exclusive)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// profiled runs input with a profiler whose clock moves on a millisecond each
+// time it's read.
+func profiled(input string) *Interpreter {
+	var o strings.Builder
+	interpreter := NewInterpreter(&o, input)
+	clock := time.Unix(0, 0)
+	interpreter.profile().now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+	for {
+		w, err := interpreter.Word()
+		if err != nil {
+			break
+		}
+		interpreter.Interpret(w)
+	}
+	return interpreter
+}
+
+func TestProfileReport(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedReport string
+	}{
+		"calls": {
+			input: ": square dup * ;\nprofile-start 2 square square profile-stop",
+			expectedReport: "word                      calls      inclusive      exclusive\n" +
+				"square                        2           10ms            6ms\n" +
+				"*                             2            2ms            2ms\n" +
+				"dup                           2            2ms            2ms\n" +
+				"profile-stop                  1             0s             0s\n",
+		},
+		"bye": {
+			input: ": square dup * ;\nprofile-start 2 square bye square",
+			expectedReport: "word                      calls      inclusive      exclusive\n" +
+				"square                        1            5ms            3ms\n" +
+				"*                             1            1ms            1ms\n" +
+				"bye                           1            1ms            1ms\n" +
+				"dup                           1            1ms            1ms\n",
+		},
+		"recursion": {
+			input: ": down dup 0 > if 1 - recurse then ;\nprofile-start 1 down profile-stop",
+			expectedReport: "word                      calls      inclusive      exclusive\n" +
+				"down                          1           23ms            5ms\n" +
+				"if                            2           15ms            5ms\n" +
+				"recurse                       1            9ms            5ms\n" +
+				">                             2            2ms            2ms\n" +
+				"dup                           2            2ms            2ms\n" +
+				"-                             1            1ms            1ms\n" +
+				"profile-stop                  1             0s             0s\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var report strings.Builder
+			if err := profiled(test.input).WriteProfileReport(&report); err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedReport != report.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedReport, report.String())
+			}
+		})
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	interpreter := profiled(": square dup * ;\n: cube dup square * ;\nprofile-start 2 cube profile-stop")
+	var b bytes.Buffer
+	if err := interpreter.WriteProfile(&b); err != nil {
+		t.Fatal(err)
+	}
+	z, err := gzip.NewReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := map[uint64][][]byte{}
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		length, m := readVarint(data[n:])
+		if key&7 != wireBytes {
+			data = data[n+m:]
+			continue
+		}
+		fields[key>>3] = append(fields[key>>3], data[n+m:n+m+int(length)])
+		data = data[n+m+int(length):]
+	}
+
+	var table []string
+	for _, s := range fields[6] {
+		table = append(table, string(s))
+	}
+	for _, expected := range []string{"", "calls", "count", "time", "nanoseconds", "cube", "square", "dup", "*"} {
+		if !slices.Contains(table, expected) {
+			t.Errorf("expected %q in the string table %q", expected, table)
+		}
+	}
+	if table[0] != "" {
+		t.Errorf("expected the first string to be empty, got %q", table[0])
+	}
+	// cube, cube dup, cube *, cube square, cube square dup and cube square *,
+	// profile-stop stops profiling before it finishes
+	if len(fields[2]) != 6 {
+		t.Errorf("expected 6 samples, got %d", len(fields[2]))
+	}
+	if len(fields[5]) != 4 {
+		t.Errorf("expected 4 functions, got %d", len(fields[5]))
+	}
+}
+
+func readVarint(data []byte) (uint64, int) {
+	var x uint64
+	for n, b := range data {
+		x |= uint64(b&0x7f) << (7 * n)
+		if b < 0x80 {
+			return x, n + 1
+		}
+	}
+	return x, len(data)
+}
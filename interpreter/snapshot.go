@@ -0,0 +1,59 @@
+package interpreter
+
+import "maps"
+
+// Snapshot is the state of an interpreter, its stacks, dictionary and data
+// space, which it can be restored to.
+type Snapshot struct {
+	dictionary dictionaryState
+	stack      []int
+	loopStack  []int
+	memory     []byte
+	actions    map[*ExecutableToken]*ExecutableToken
+	immediate  map[*ExecutableToken]bool
+	included   map[string]bool
+	buffers    []blockBuffer
+}
+
+// Snapshot records the state of the interpreter.
+func (i *Interpreter) Snapshot() *Snapshot {
+	s := &Snapshot{
+		dictionary: i.dictionaryState(),
+		stack:      append([]int{}, i.stack.items...),
+		loopStack:  append([]int{}, i.loopStack.items...),
+		memory:     append([]byte{}, i.memory.bytes...),
+		actions:    make(map[*ExecutableToken]*ExecutableToken),
+		immediate:  make(map[*ExecutableToken]bool),
+		included:   maps.Clone(i.included),
+	}
+	for _, word := range i.xts {
+		s.immediate[word] = word.immediate
+		if word.deferred {
+			s.actions[word] = word.action
+		}
+	}
+	if i.blocks != nil {
+		s.buffers = append([]blockBuffer{}, i.blocks.buffers...)
+	}
+	return s
+}
+
+// Restore returns the interpreter to the state recorded by the snapshot,
+// removing the words defined since and undoing changes to the stacks, data
+// space and the words made immediate.
+func (i *Interpreter) Restore(s *Snapshot) {
+	i.rollback(s.dictionary)
+	i.stack = Stack[int]{items: append([]int{}, s.stack...)}
+	i.loopStack = Stack[int]{items: append([]int{}, s.loopStack...)}
+	i.memory.bytes = append([]byte{}, s.memory...)
+	for word, action := range s.actions {
+		word.action = action
+	}
+	for word, immediate := range s.immediate {
+		word.immediate = immediate
+	}
+	i.included = maps.Clone(s.included)
+	if i.blocks != nil && len(s.buffers) == len(i.blocks.buffers) {
+		copy(i.blocks.buffers, s.buffers)
+	}
+}
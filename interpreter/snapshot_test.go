@@ -0,0 +1,80 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	tests := map[string]struct {
+		before         string
+		after          string
+		then           string
+		expectedOutput string
+		expectedStack  []int
+	}{
+		"stack": {
+			before:         "1 2",
+			after:          "drop drop 3",
+			expectedOutput: "",
+			expectedStack:  []int{1, 2},
+		},
+		"new definitions": {
+			before:         ": one 1 ;",
+			after:          ": two 2 ;",
+			then:           "one two",
+			expectedOutput: "two ?\n",
+			expectedStack:  []int{1},
+		},
+		"redefinitions": {
+			before:         ": one 1 ;",
+			after:          ": one 11 ;",
+			then:           "one",
+			expectedOutput: "redefined one\n",
+			expectedStack:  []int{1},
+		},
+		"memory": {
+			before:         "variable x 5 x !",
+			after:          "7 x ! 10 allot",
+			then:           "x @ here x cell+ =",
+			expectedOutput: "",
+			expectedStack:  []int{5, -1},
+		},
+		"deferred words": {
+			before:         "defer greet : hi 1 ; : hello 2 ; ' hi is greet",
+			after:          "' hello is greet",
+			then:           "greet",
+			expectedOutput: "",
+			expectedStack:  []int{1},
+		},
+	}
+
+	run := func(i *Interpreter, input string) {
+		i.SetScanLine(input)
+		for {
+			w, err := i.Word()
+			if err != nil {
+				break
+			}
+			i.Interpret(w)
+		}
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			interpreter := NewInterpreter(&o, "")
+			run(interpreter, test.before)
+			snapshot := interpreter.Snapshot()
+			run(interpreter, test.after)
+			interpreter.Restore(snapshot)
+			run(interpreter, test.then)
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+
+			ValidateStack(t, interpreter.stack, test.expectedStack)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// The trace levels, how much is written for each word traced.
+const (
+	// TraceWords writes the words run, indented by their depth
+	TraceWords = iota + 1
+	// TraceStacks also writes the stack before and after each word
+	TraceStacks
+	// TraceLocations also writes where each word was written
+	TraceLocations
+)
+
+// tracer writes the words run to its own writer.
+type tracer struct {
+	out   io.Writer
+	level int
+	hook  *ExecutionHook
+
+	// words are the words traced along with the words they run, all words
+	// are traced when it's empty
+	words map[string]bool
+
+	// calls are the words being run, the innermost last, with whether each
+	// is traced and the stack before it was run
+	calls []traceCall
+}
+
+type traceCall struct {
+	traced bool
+	stack  string
+}
+
+// WithTraceOutput sets the writer traces are written to, by default they are
+// written to the error output.
+func WithTraceOutput(w io.Writer) Option {
+	return func(i *Interpreter) {
+		i.trace().out = w
+	}
+}
+
+// trace returns the interpreter's tracer.
+func (i *Interpreter) trace() *tracer {
+	if i.tracer == nil {
+		t := &tracer{level: TraceStacks, words: make(map[string]bool)}
+		t.hook = &ExecutionHook{
+			Before: func(e Event) { i.traceBefore(t, e) },
+			After:  func(e Event) { i.traceAfter(t, e) },
+		}
+		i.tracer = t
+	}
+	return i.tracer
+}
+
+// SetTrace turns tracing on or off.
+func (i *Interpreter) SetTrace(on bool) {
+	t := i.trace()
+	t.calls = nil
+	i.setHook(t.hook, on)
+}
+
+// SetTraceLevel sets how much is written for each word, TraceStacks by
+// default.
+func (i *Interpreter) SetTraceLevel(level int) {
+	i.trace().level = level
+}
+
+// TraceOnly limits tracing to the named words and the words they run, with
+// no names every word is traced.
+func (i *Interpreter) TraceOnly(names ...string) {
+	t := i.trace()
+	if len(names) == 0 {
+		t.words = make(map[string]bool)
+	}
+	for _, name := range names {
+		t.words[name] = true
+	}
+}
+
+func (i *Interpreter) traceBefore(t *tracer, e Event) {
+	traced := len(t.words) == 0 || t.words[e.Word] ||
+		(len(t.calls) > 0 && t.calls[len(t.calls)-1].traced)
+	call := traceCall{traced: traced}
+	if traced {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s> %s", strings.Repeat("  ", e.Depth), e.Word)
+		if t.level >= TraceStacks {
+			call.stack = i.formatStack()
+			fmt.Fprintf(&b, " %s", call.stack)
+		}
+		if t.level >= TraceLocations {
+			b.WriteString(" (")
+			if e.File != "" {
+				fmt.Fprintf(&b, "%s:", e.File)
+			}
+			fmt.Fprintf(&b, "%d)", e.Line)
+		}
+		i.traceLine(t, b.String())
+	}
+	t.calls = append(t.calls, call)
+}
+
+func (i *Interpreter) traceAfter(t *tracer, e Event) {
+	// tracing may have been turned on by the word
+	if len(t.calls) == 0 {
+		return
+	}
+	call := t.calls[len(t.calls)-1]
+	t.calls = t.calls[:len(t.calls)-1]
+	if call.traced && t.level >= TraceStacks {
+		i.traceLine(t, fmt.Sprintf("%s< %s %s", strings.Repeat("  ", e.Depth), e.Word, i.formatStack()))
+	}
+}
+
+func (i *Interpreter) traceLine(t *tracer, line string) {
+	out := t.out
+	if out == nil {
+		out = i.errOut
+	}
+	_, err := fmt.Fprintln(out, line)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// formatStack returns the depth of the stack and its items, i.e. <2> 1 2.
+func (i *Interpreter) formatStack() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>", len(i.stack.items))
+	for _, v := range i.stack.items {
+		fmt.Fprintf(&b, " %d", v)
+	}
+	return b.String()
+}
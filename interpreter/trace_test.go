@@ -0,0 +1,61 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedTrace  string
+		expectedOutput string
+	}{
+		"stacks": {
+			input: ": square dup * ;\n3 trace on square trace off 2 drop",
+			expectedTrace: "> square <1> 3\n  > dup <1> 3\n  < dup <2> 3 3\n  > * <2> 3 3\n  < * <1> 9\n< square <1> 9\n" +
+				"> trace <1> 9\n",
+		},
+		"words": {
+			input:         ": square dup * ;\n1 trace-level trace on 3 square",
+			expectedTrace: "> 3\n> square\n  > dup\n  > *\n",
+		},
+		"locations": {
+			input:         ": square\n  dup * ;\n3 3 trace-level trace on square",
+			expectedTrace: "> square <1> 3 (3)\n  > dup <1> 3 (2)\n  < dup <2> 3 3\n  > * <2> 3 3 (2)\n  < * <1> 9\n< square <1> 9\n",
+		},
+		"only": {
+			input:         ": square dup * ;\n: cube dup square * ;\n1 trace-level trace-only square trace on 2 cube trace-all 1",
+			expectedTrace: "  > square\n    > dup\n    > *\n> 1\n",
+		},
+		"invalid level": {
+			input:          "4 trace-level",
+			expectedOutput: "invalid trace level\n",
+		},
+		"missing setting": {
+			input:          "trace",
+			expectedOutput: "trace must be followed by on or off\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o, trace strings.Builder
+			interpreter := NewInterpreter(&o, test.input, WithTraceOutput(&trace))
+			for {
+				w, err := interpreter.Word()
+				if err != nil {
+					break
+				}
+				interpreter.Interpret(w)
+			}
+
+			if test.expectedTrace != trace.String() {
+				t.Errorf("expected trace '%v', got '%v'", test.expectedTrace, trace.String())
+			}
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package interpreter
+
+// Wordlist is a list of words in the order they were defined, a name refers
+// to the newest word defined with it.
+type Wordlist struct {
+	name  string
+	words []*ExecutableToken
+	index map[string]*ExecutableToken
+}
+
+func NewWordlist(name string) *Wordlist {
+	return &Wordlist{
+		name:  name,
+		index: make(map[string]*ExecutableToken),
+	}
+}
+
+func (w *Wordlist) Add(word *ExecutableToken) {
+	w.words = append(w.words, word)
+	w.index[word.name] = word
+}
+
+func (w *Wordlist) Find(name string) (*ExecutableToken, bool) {
+	word, ok := w.index[name]
+	return word, ok
+}
+
+// Truncate removes all but the first n words, so names refer to the words
+// they did before the rest were defined.
+func (w *Wordlist) Truncate(n int) {
+	if n >= len(w.words) {
+		return
+	}
+	w.words = w.words[:n]
+	w.index = make(map[string]*ExecutableToken)
+	for _, word := range w.words {
+		w.index[word.name] = word
+	}
+}
+
+// Visible reports whether word is the one its name refers to, rather than
+// one that has been redefined.
+func (w *Wordlist) Visible(word *ExecutableToken) bool {
+	return w.index[word.name] == word
+}
@@ -0,0 +1,58 @@
+package interpreter
+
+import (
+	"testing"
+)
+
+func TestWordlistFind(t *testing.T) {
+	wordlist := NewWordlist("test")
+
+	if _, ok := wordlist.Find("one"); ok {
+		t.Errorf("expected one not to be found")
+	}
+
+	first := &ExecutableToken{name: "one"}
+	wordlist.Add(first)
+	word, ok := wordlist.Find("one")
+	if !ok || word != first {
+		t.Errorf("expected to find the first definition of one")
+	}
+
+	second := &ExecutableToken{name: "one"}
+	wordlist.Add(second)
+	word, ok = wordlist.Find("one")
+	if !ok || word != second {
+		t.Errorf("expected to find the second definition of one")
+	}
+
+	if wordlist.Visible(first) {
+		t.Errorf("expected the first definition of one to be hidden")
+	}
+	if !wordlist.Visible(second) {
+		t.Errorf("expected the second definition of one to be visible")
+	}
+	if len(wordlist.words) != 2 {
+		t.Errorf("expected 2 words, got %d", len(wordlist.words))
+	}
+}
+
+func TestWordlistTruncate(t *testing.T) {
+	wordlist := NewWordlist("test")
+	first := &ExecutableToken{name: "one"}
+	wordlist.Add(first)
+	wordlist.Add(&ExecutableToken{name: "two"})
+	wordlist.Add(&ExecutableToken{name: "one"})
+
+	wordlist.Truncate(1)
+
+	if len(wordlist.words) != 1 {
+		t.Errorf("expected 1 word, got %d", len(wordlist.words))
+	}
+	word, ok := wordlist.Find("one")
+	if !ok || word != first {
+		t.Errorf("expected to find the first definition of one")
+	}
+	if _, ok := wordlist.Find("two"); ok {
+		t.Errorf("expected two not to be found")
+	}
+}
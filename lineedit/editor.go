@@ -0,0 +1,437 @@
+// Package lineedit reads lines from a terminal, letting them be edited with
+// the arrow keys and the usual Emacs style control keys, with a history of the
+// lines read, reverse search and tab completion. When the input isn't a
+// terminal it reads plain lines.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl-C is pressed.
+var ErrInterrupted = errors.New("interrupted")
+
+// The keys read by readKey that aren't characters.
+const (
+	keyUp = -(iota + 1)
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyEscape
+)
+
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	ctrlH     = 8
+	tab       = 9
+	ctrlJ     = 10
+	ctrlK     = 11
+	ctrlL     = 12
+	enter     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	ctrlW     = 23
+	backspace = 127
+)
+
+// Editor reads lines for the REPL.
+type Editor struct {
+	reader   *bufio.Reader
+	out      io.Writer
+	fd       int
+	terminal bool
+
+	history     []string
+	historyFile string
+
+	// Complete returns the words Tab can complete, those starting with the
+	// text before the cursor are offered.
+	Complete func() []string
+}
+
+// New returns an editor reading from in, which is edited in place when it's
+// a terminal, and echoing to out.
+func New(in io.Reader, out io.Writer) *Editor {
+	e := &Editor{
+		reader: bufio.NewReader(in),
+		out:    out,
+	}
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		e.fd = int(f.Fd())
+		e.terminal = true
+	}
+	return e
+}
+
+// Reader returns the buffered reader of the editor's input, so other readers
+// of the input share what the editor has buffered.
+func (e *Editor) Reader() *bufio.Reader {
+	return e.reader
+}
+
+// ReadLine shows the prompt and returns the line entered, without its line
+// terminator. It returns io.EOF when Ctrl-D is pressed on an empty line and
+// ErrInterrupted when Ctrl-C is pressed.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.terminal {
+		return e.readPlain(prompt)
+	}
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer restore()
+
+	line, err := e.edit(prompt)
+	if err != nil {
+		return "", err
+	}
+	e.AddHistory(line)
+	return line, nil
+}
+
+func (e *Editor) readPlain(prompt string) (string, error) {
+	if _, err := fmt.Fprint(e.out, prompt); err != nil {
+		return "", err
+	}
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// state is the line being edited.
+type state struct {
+	e      *Editor
+	prompt string
+	buf    []rune
+	pos    int
+
+	// history is the entry being shown, len(e.history) for the new line,
+	// which is kept in saved while looking through the history
+	history int
+	saved   []rune
+}
+
+// edit reads keys from a terminal in raw mode until the line is entered.
+func (e *Editor) edit(prompt string) (string, error) {
+	s := &state{e: e, prompt: prompt, history: len(e.history)}
+	s.refresh()
+
+	var last rune
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		if key == ctrlR {
+			if key, err = s.search(); err != nil {
+				return "", err
+			}
+		}
+
+		switch key {
+		case enter, ctrlJ:
+			e.write("\r\n")
+			return string(s.buf), nil
+		case ctrlC:
+			e.write("^C\r\n")
+			return "", ErrInterrupted
+		case ctrlD:
+			if len(s.buf) == 0 {
+				e.write("\r\n")
+				return "", io.EOF
+			}
+			s.delete(s.pos, s.pos+1)
+		case keyDelete:
+			s.delete(s.pos, s.pos+1)
+		case backspace, ctrlH:
+			s.delete(s.pos-1, s.pos)
+		case ctrlW:
+			start := s.pos
+			for start > 0 && unicode.IsSpace(s.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+				start--
+			}
+			s.delete(start, s.pos)
+		case ctrlK:
+			s.delete(s.pos, len(s.buf))
+		case ctrlU:
+			s.delete(0, s.pos)
+		case keyLeft, ctrlB:
+			s.move(s.pos - 1)
+		case keyRight, ctrlF:
+			s.move(s.pos + 1)
+		case keyHome, ctrlA:
+			s.move(0)
+		case keyEnd, ctrlE:
+			s.move(len(s.buf))
+		case keyUp, ctrlP:
+			s.showHistory(s.history - 1)
+		case keyDown, ctrlN:
+			s.showHistory(s.history + 1)
+		case ctrlL:
+			e.write("\x1b[H\x1b[2J")
+			s.refresh()
+		case tab:
+			s.complete(last == tab)
+		case keyEscape, ctrlG:
+		default:
+			if key >= ' ' {
+				s.insert([]rune{key})
+			}
+		}
+		last = key
+	}
+}
+
+// readKey reads a key, translating the escape sequences sent by the arrow
+// keys and the like.
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.reader.ReadRune()
+	if err != nil || r != 27 {
+		return r, err
+	}
+
+	// a sequence arrives all at once, while escape pressed on its own is
+	// followed by nothing until the next key, which is left to be read
+	if e.reader.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	r, _, err = e.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != '[' && r != 'O' {
+		_ = e.reader.UnreadRune()
+		return keyEscape, nil
+	}
+	// the parameters, like the 1;5 of ESC [ 1 ; 5 C sent by Ctrl-Right, run
+	// up to a final byte from @ to ~
+	var parameters string
+	for {
+		r, _, err = e.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r >= 0x40 && r <= 0x7e {
+			break
+		}
+		parameters += string(r)
+	}
+	switch r {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '~':
+		switch parameters {
+		case "1", "7":
+			return keyHome, nil
+		case "4", "8":
+			return keyEnd, nil
+		case "3":
+			return keyDelete, nil
+		}
+	}
+	// other sequences are ignored
+	return keyEscape, nil
+}
+
+func (e *Editor) write(s string) {
+	_, _ = io.WriteString(e.out, s)
+}
+
+// refresh redraws the line, leaving the cursor at pos.
+func (s *state) refresh() {
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(s.prompt)
+	b.WriteString(string(s.buf))
+	b.WriteString("\x1b[K\r")
+	if n := utf8.RuneCountInString(s.prompt) + s.pos; n > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", n)
+	}
+	s.e.write(b.String())
+}
+
+func (s *state) insert(r []rune) {
+	s.buf = append(s.buf[:s.pos], append(r, s.buf[s.pos:]...)...)
+	s.pos += len(r)
+	s.refresh()
+}
+
+// delete removes the characters from start up to end.
+func (s *state) delete(start int, end int) {
+	start = max(start, 0)
+	end = min(end, len(s.buf))
+	if start >= end {
+		return
+	}
+	s.buf = append(s.buf[:start], s.buf[end:]...)
+	if s.pos > end {
+		s.pos -= end - start
+	} else if s.pos > start {
+		s.pos = start
+	}
+	s.refresh()
+}
+
+func (s *state) move(pos int) {
+	if pos < 0 || pos > len(s.buf) {
+		return
+	}
+	s.pos = pos
+	s.refresh()
+}
+
+func (s *state) set(line []rune) {
+	s.buf = append([]rune{}, line...)
+	s.pos = len(s.buf)
+	s.refresh()
+}
+
+// showHistory replaces the line with entry n of the history.
+func (s *state) showHistory(n int) {
+	if n < 0 || n > len(s.e.history) || n == s.history {
+		return
+	}
+	if s.history == len(s.e.history) {
+		s.saved = s.buf
+	}
+	s.history = n
+	if n == len(s.e.history) {
+		s.set(s.saved)
+	} else {
+		s.set([]rune(s.e.history[n]))
+	}
+}
+
+// complete completes the word before the cursor with the words starting with
+// it, as far as they agree, listing them when asked twice.
+func (s *state) complete(again bool) {
+	start := s.pos
+	for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+		start--
+	}
+	prefix := string(s.buf[start:s.pos])
+
+	var matches []string
+	if s.e.Complete != nil {
+		for _, word := range s.e.Complete() {
+			if strings.HasPrefix(word, prefix) {
+				matches = append(matches, word)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		s.e.write("\a")
+	case len(matches) == 1:
+		s.insert([]rune(matches[0][len(prefix):] + " "))
+	default:
+		common := matches[0]
+		for _, match := range matches[1:] {
+			for !strings.HasPrefix(match, common) {
+				common = common[:len(common)-1]
+			}
+		}
+		if len(common) > len(prefix) {
+			s.insert([]rune(common[len(prefix):]))
+		} else if again {
+			s.e.write("\r\n" + strings.Join(matches, " ") + "\r\n")
+			s.refresh()
+		} else {
+			s.e.write("\a")
+		}
+	}
+}
+
+// search looks back through the history for lines containing the text typed,
+// until a key other than those editing the search is pressed. The line found
+// replaces the one being edited, unless the search is cancelled with Ctrl-G,
+// and the key that ended the search is returned.
+func (s *state) search() (rune, error) {
+	var query []rune
+	match := len(s.e.history)
+
+	find := func(from int) {
+		for n := from; n >= 0; n-- {
+			if strings.Contains(s.e.history[n], string(query)) {
+				match = n
+				return
+			}
+		}
+	}
+	show := func() {
+		line := ""
+		if match < len(s.e.history) {
+			line = s.e.history[match]
+		}
+		s.e.write(fmt.Sprintf("\r(reverse-i-search)`%s': %s\x1b[K", string(query), line))
+	}
+
+	show()
+	for {
+		key, err := s.e.readKey()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case key == ctrlR:
+			find(match - 1)
+		case key == backspace || key == ctrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			match = len(s.e.history)
+			find(match - 1)
+		case key == ctrlG || key == keyEscape:
+			s.refresh()
+			return key, nil
+		case key >= ' ':
+			query = append(query, key)
+			find(min(match, len(s.e.history)-1))
+		default:
+			if match < len(s.e.history) {
+				s.history = len(s.e.history)
+				s.set([]rune(s.e.history[match]))
+			} else {
+				s.refresh()
+			}
+			return key, nil
+		}
+		show()
+	}
+}
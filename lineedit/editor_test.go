@@ -0,0 +1,180 @@
+package lineedit
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	tests := map[string]struct {
+		keys     string
+		history  []string
+		words    []string
+		expected string
+		err      error
+	}{
+		"typing": {
+			keys:     "1 2 +\r",
+			expected: "1 2 +",
+		},
+		"backspace": {
+			keys:     "1 2 -\x7f+\r",
+			expected: "1 2 +",
+		},
+		"moving the cursor": {
+			keys:     "1 +\x1b[D2 \x1b[H0 \x05 .\r",
+			expected: "0 1 2 + .",
+		},
+		"escape on its own": {
+			keys:     "ab\x1bc\r",
+			expected: "abc",
+		},
+		"modified arrow keys": {
+			keys:     "ac\x1b[1;5Db\x1b[1;5Cd\x1b[200~\r",
+			expected: "abcd",
+		},
+		"deleting": {
+			keys:     "one two three\x17\x17four\x01\x04\x1b[3~\r",
+			expected: "e four",
+		},
+		"killing": {
+			keys:     "one two\x02\x02\x02\x0b\x01\x06\x15\r",
+			expected: "ne ",
+		},
+		"multibyte characters": {
+			keys:     "héllo\x02\x02\x02\x7f\r",
+			expected: "hllo",
+		},
+		"history": {
+			keys:     "\x1b[A\x1b[A\x1b[A\x1b[B\r",
+			history:  []string{"first", "second"},
+			expected: "second",
+		},
+		"back to the new line": {
+			keys:     "new\x10\x0e\r",
+			history:  []string{"old"},
+			expected: "new",
+		},
+		"reverse search": {
+			keys:     "\x12dup\r",
+			history:  []string{"dup *", "2 dup +", "swap"},
+			expected: "2 dup +",
+		},
+		"reverse search again": {
+			keys:     "\x12dup\x12\x05 .\r",
+			history:  []string{"dup *", "2 dup +", "swap"},
+			expected: "dup * .",
+		},
+		"cancelled reverse search": {
+			keys:     "x\x12dup\x07y\r",
+			history:  []string{"dup *"},
+			expected: "xy",
+		},
+		"completion": {
+			keys:     "2 sq\t\r",
+			words:    []string{"dup", "square", "swap"},
+			expected: "2 square ",
+		},
+		"completing a common prefix": {
+			keys:     "s\t\r",
+			words:    []string{"save-buffers", "save-input"},
+			expected: "save-",
+		},
+		"ctrl-d on an empty line": {
+			keys: "\x04",
+			err:  io.EOF,
+		},
+		"ctrl-c": {
+			keys: "abc\x03",
+			err:  ErrInterrupted,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o strings.Builder
+			e := &Editor{
+				reader:  bufio.NewReader(strings.NewReader(test.keys)),
+				out:     &o,
+				history: test.history,
+			}
+			if test.words != nil {
+				e.Complete = func() []string { return test.words }
+			}
+
+			line, err := e.edit("> ")
+			if err != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if line != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, line)
+			}
+		})
+	}
+}
+
+func TestCompletionList(t *testing.T) {
+	var o strings.Builder
+	e := &Editor{
+		reader:   bufio.NewReader(strings.NewReader("s\t\t\r")),
+		out:      &o,
+		Complete: func() []string { return []string{"see", "swap"} },
+	}
+	if _, err := e.edit("> "); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(o.String(), "\r\nsee swap\r\n") {
+		t.Errorf("expected the completions to be listed, got %q", o.String())
+	}
+}
+
+func TestPlainInput(t *testing.T) {
+	var o strings.Builder
+	e := New(strings.NewReader("1 2 +\r\nlast"), &o)
+
+	for _, expected := range []string{"1 2 +", "last"} {
+		line, err := e.ReadLine("ok> ")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if line != expected {
+			t.Errorf("expected %q, got %q", expected, line)
+		}
+	}
+	if _, err := e.ReadLine("ok> "); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+	if o.String() != "ok> ok> ok> " {
+		t.Errorf("expected the prompts to be shown, got %q", o.String())
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(name, []byte("one\ntwo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(strings.NewReader(""), io.Discard)
+	if err := e.LoadHistory(name); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	e.AddHistory("two")
+	e.AddHistory("  ")
+	e.AddHistory("three")
+
+	if strings.Join(e.history, ",") != "one,two,three" {
+		t.Errorf("expected one,two,three, got %v", e.history)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "one\ntwo\nthree\n" {
+		t.Errorf("expected the new line to be saved, got %q", b)
+	}
+}
@@ -0,0 +1,60 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 1000
+
+// LoadHistory reads the history from the named file, if it exists, and saves
+// the lines read from then on to it.
+func (e *Editor) LoadHistory(name string) error {
+	e.historyFile = name
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e.add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// AddHistory adds the line to the history, unless it is blank or the same as
+// the last line. Saving the history is best effort, a history file that
+// can't be written to doesn't stop the line being read.
+func (e *Editor) AddHistory(line string) {
+	if !e.add(line) || e.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.WriteString(line + "\n")
+}
+
+func (e *Editor) add(line string) bool {
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return false
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+	return true
+}
@@ -1,52 +1,206 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/JohnCrickett/goforth/interpreter"
+	"github.com/JohnCrickett/goforth/repl"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
 )
 
 func main() {
+	protect := flag.Bool("protect", false, "stop built-in words from being redefined")
+	noStd := flag.Bool("no-std", false, "don't load the standard library at startup")
+	blocks := flag.String("blocks", "", "the file to store blocks in")
+	trace := flag.Bool("trace", false, "trace the words run")
+	traceFile := flag.String("trace-file", "", "the file to write the trace to, instead of stderr")
+	traceWords := flag.String("trace-words", "", "a comma separated list of the words to trace, with the words they run")
+	traceLevel := flag.Int("trace-level", interpreter.TraceStacks, "1 traces the words, 2 adds the stacks, 3 adds the locations")
+	profile := flag.String("profile", "", "profile the words run, writing a pprof profile to the file and a report to stderr")
+	cover := flag.Bool("cover", false, "record the words run from the files included, writing a report to stderr")
+	coverHTML := flag.String("cover-html", "", "record coverage, writing an HTML view of the files included to the file")
 	flag.Parse()
+
+	traceOutput := io.Writer(os.Stderr)
+	if *traceFile != "" {
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		traceOutput = f
+	}
+	startTrace := func(i *interpreter.Interpreter) {
+		if !*trace {
+			return
+		}
+		i.SetTraceLevel(*traceLevel)
+		if *traceWords != "" {
+			i.TraceOnly(strings.Split(*traceWords, ",")...)
+		}
+		i.SetTrace(true)
+	}
+	startProfile := func(i *interpreter.Interpreter) {
+		if *profile != "" {
+			i.StartProfile()
+		}
+	}
+	startCoverage := func(i *interpreter.Interpreter) {
+		if *cover || *coverHTML != "" {
+			i.StartCoverage()
+		}
+	}
+	stopCoverage := func(i *interpreter.Interpreter) {
+		if !*cover && *coverHTML == "" {
+			return
+		}
+		i.StopCoverage()
+		if err := i.WriteCoverageReport(os.Stderr); err != nil {
+			log.Fatal(err)
+		}
+		if *coverHTML == "" {
+			return
+		}
+		f, err := os.Create(*coverHTML)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err := i.WriteCoverageHTML(f); err != nil {
+			log.Fatal(err)
+		}
+	}
+	stopProfile := func(i *interpreter.Interpreter) {
+		if *profile == "" {
+			return
+		}
+		i.StopProfile()
+		if err := i.WriteProfileReport(os.Stderr); err != nil {
+			log.Fatal(err)
+		}
+		f, err := os.Create(*profile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err := i.WriteProfile(f); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	filenames := flag.Args()
+	if len(filenames) > 0 && filenames[0] == "check" {
+		os.Exit(check(filenames[1:], *noStd))
+	}
 	if len(filenames) > 1 {
 		log.Fatal("only one file can be specified")
 	}
 	if len(filenames) == 1 {
-		sb, err := os.ReadFile(filenames[0])
+		i := interpreter.NewInterpreter(os.Stdout, "", interpreter.WithTraceOutput(traceOutput))
+		i.ProtectBuiltins(*protect)
+		if *blocks != "" {
+			if err := i.SetBlockFile(*blocks); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if !*noStd {
+			if err := i.Require("std"); err != nil {
+				log.Fatal(err)
+			}
+		}
+		startTrace(i)
+		startProfile(i)
+		startCoverage(i)
+		// bye ends the program here, so the reports are still written
+		if err := i.Include(filenames[0]); err != nil && !errors.Is(err, interpreter.ErrBye) {
+			log.Fatal(err)
+		}
+		stopProfile(i)
+		stopCoverage(i)
+		if err := i.FlushBlocks(); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		options := []repl.Option{repl.WithInterpreterOptions(interpreter.WithTraceOutput(traceOutput))}
+		if home, err := os.UserHomeDir(); err == nil {
+			options = append(options, repl.WithHistory(filepath.Join(home, ".goforth_history")))
+		}
+		session, err := repl.New(os.Stdin, os.Stdout, os.Stderr, options...)
 		if err != nil {
 			log.Fatal(err)
 		}
-		source := string(sb)
-		i := interpreter.NewInterpreter(os.Stdout, source)
-		for {
-			w, err := i.Word()
-			if err != nil {
-				break
+		i := session.Interpreter()
+		i.ProtectBuiltins(*protect)
+		if *blocks != "" {
+			if err := i.SetBlockFile(*blocks); err != nil {
+				log.Fatal(err)
 			}
-			i.Interpret(w)
 		}
+		if !*noStd {
+			if err := i.Require("std"); err != nil {
+				log.Fatal(err)
+			}
+		}
+		startTrace(i)
+		startProfile(i)
+		startCoverage(i)
 
-	} else {
-		reader := bufio.NewReader(os.Stdin)
-		i := interpreter.NewInterpreter(os.Stdout, "")
-
-		for {
-			word, err := i.Word()
-			if err != nil {
-				i.Prompt()
-				s, err := reader.ReadString('\n')
-				if err != nil {
-					log.Fatal(err)
+		// Ctrl-C interrupts the word being run, pressing it twice at the
+		// prompt exits
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt)
+		go func() {
+			for range signals {
+				if session.Interrupt() {
+					stopProfile(i)
+					stopCoverage(i)
+					if err := i.FlushBlocks(); err != nil {
+						log.Fatal(err)
+					}
+					os.Exit(0)
 				}
-				s = strings.TrimSpace(s)
-				i.SetScanLine(s)
-			} else {
-				i.Interpret(word)
 			}
+		}()
+
+		if err := session.Run(); err != nil {
+			log.Fatal(err)
+		}
+		stopProfile(i)
+		stopCoverage(i)
+		if err := i.FlushBlocks(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// check reports the problems with the stack effects in the files, without
+// running them, returning the exit status.
+func check(filenames []string, noStd bool) int {
+	if len(filenames) == 0 {
+		log.Fatal("check needs the files to check")
+	}
+	i := interpreter.NewInterpreter(os.Stdout, "")
+	if !noStd {
+		if err := i.Require("std"); err != nil {
+			log.Fatal(err)
+		}
+	}
+	status := 0
+	for _, filename := range filenames {
+		problems, err := i.Check(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+			status = 1
 		}
 	}
+	return status
 }
@@ -0,0 +1,284 @@
+// Package repl runs an interactive session with the interpreter, reading
+// lines from any reader and writing to any writer, so it can be hosted on a
+// terminal, over a pipe or a socket, or in tests.
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"github.com/JohnCrickett/goforth/interpreter"
+	"github.com/JohnCrickett/goforth/lineedit"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// PromptFormat is the format of the prompt shown before each line.
+type PromptFormat struct {
+	// ShowDepth shows the number of items on the stack, i.e. <2>
+	ShowDepth bool
+	// ShowStack shows the items on the stack, the top of the stack last
+	ShowStack bool
+	// Prompt follows the depth and stack
+	Prompt string
+	// Continuation is shown instead for the lines of a definition that
+	// continues over several lines
+	Continuation string
+}
+
+// DefaultPrompt shows the stack followed by ok>.
+var DefaultPrompt = PromptFormat{
+	ShowStack:    true,
+	Prompt:       "ok> ",
+	Continuation: "...> ",
+}
+
+// Session reads lines and interprets them until the end of the input.
+type Session struct {
+	interpreter *interpreter.Interpreter
+	editor      *lineedit.Editor
+	out         io.Writer
+	errOut      io.Writer
+
+	format PromptFormat
+	prompt string
+
+	// last is the last line read, input the lines read for the input being
+	// interpreted, more than one when a definition continues over several
+	// lines, which continued marks as being read
+	last      string
+	input     []string
+	continued bool
+
+	// snapshots are the states of the interpreter before the last lines,
+	// the last first, for undo
+	snapshots []*interpreter.Snapshot
+	undoLimit int
+
+	history            string
+	beforeLine         func(line string)
+	afterLine          func(line string)
+	interpreterOptions []interpreter.Option
+
+	// reading is true while waiting for a line at the prompt, interrupts
+	// counts the interrupts since the last line was read
+	reading    atomic.Bool
+	interrupts atomic.Int32
+}
+
+// Option configures a Session created by New.
+type Option func(*Session)
+
+// WithPrompt sets the format of the prompt, DefaultPrompt by default.
+func WithPrompt(format PromptFormat) Option {
+	return func(s *Session) {
+		s.format = format
+	}
+}
+
+// WithUndoLimit sets the number of lines that can be undone, 20 by default.
+func WithUndoLimit(n int) Option {
+	return func(s *Session) {
+		s.undoLimit = n
+	}
+}
+
+// WithHistory keeps the history of the lines entered in the named file.
+func WithHistory(name string) Option {
+	return func(s *Session) {
+		s.history = name
+	}
+}
+
+// WithBeforeLine sets a function called with each line read, before it is
+// interpreted, including the lines a definition continues over.
+func WithBeforeLine(hook func(line string)) Option {
+	return func(s *Session) {
+		s.beforeLine = hook
+	}
+}
+
+// WithAfterLine sets a function called with the input after it has been
+// interpreted, the lines of a definition that continues over several lines
+// separated by newlines.
+func WithAfterLine(hook func(line string)) Option {
+	return func(s *Session) {
+		s.afterLine = hook
+	}
+}
+
+// WithInterpreterOptions sets options for the session's interpreter.
+func WithInterpreterOptions(options ...interpreter.Option) Option {
+	return func(s *Session) {
+		s.interpreterOptions = append(s.interpreterOptions, options...)
+	}
+}
+
+// New returns a session reading lines from in, which are edited in place when
+// it's a terminal. The output of the interpreter and the prompts are written
+// to out, errors are written to errOut.
+func New(in io.Reader, out io.Writer, errOut io.Writer, options ...Option) (*Session, error) {
+	s := &Session{
+		editor:    lineedit.New(in, out),
+		out:       out,
+		errOut:    errOut,
+		format:    DefaultPrompt,
+		undoLimit: 20,
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.history != "" {
+		if err := s.editor.LoadHistory(s.history); err != nil {
+			return nil, err
+		}
+	}
+
+	s.interpreter = interpreter.NewInterpreter(out, "", append([]interpreter.Option{
+		interpreter.WithInput(s.editor.Reader()),
+		interpreter.WithErrorOutput(errOut),
+		interpreter.WithLineReader(func() (string, error) {
+			line, err := s.editor.ReadLine(s.prompt)
+			s.last = line
+			if err == nil && s.continued {
+				s.continued = false
+				s.input = append(s.input, line)
+				if s.beforeLine != nil {
+					s.beforeLine(line)
+				}
+			}
+			return line, err
+		}),
+		interpreter.WithContinuationPrompt(func() {
+			s.prompt = s.format.Continuation
+			s.continued = true
+		}),
+	}, s.interpreterOptions...)...)
+	s.editor.Complete = s.interpreter.Words
+	return s, nil
+}
+
+// Interpreter returns the session's interpreter.
+func (s *Session) Interpreter() *interpreter.Interpreter {
+	return s.interpreter
+}
+
+// Prompt returns the prompt for the next line.
+func (s *Session) Prompt() string {
+	var b strings.Builder
+	stack := s.interpreter.Stack()
+	if s.format.ShowDepth {
+		fmt.Fprintf(&b, "<%d> ", len(stack))
+	}
+	if s.format.ShowStack {
+		for _, v := range stack {
+			fmt.Fprintf(&b, "%d ", v)
+		}
+	}
+	b.WriteString(s.format.Prompt)
+	return b.String()
+}
+
+// Run reads and interprets lines until the end of the input, bye is run or
+// Ctrl-C is pressed twice at the prompt.
+func (s *Session) Run() error {
+	var line string
+	pending := false
+	for {
+		word, err := s.interpreter.Word()
+		if err == nil {
+			s.interpreter.Interpret(word)
+			continue
+		} else if errors.Is(err, interpreter.ErrBye) {
+			return nil
+		}
+
+		if pending && s.afterLine != nil {
+			s.afterLine(strings.Join(s.input, "\n"))
+		}
+		pending = false
+		s.continued = false
+
+		s.prompt = s.Prompt()
+		line, err = s.readLine()
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			if s.idleInterrupt() {
+				return nil
+			}
+			continue
+		} else if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		s.interrupts.Store(0)
+
+		if strings.TrimSpace(line) == "undo" {
+			s.interpreter.SetScanLine("")
+			if err := s.undo(); err != nil {
+				return err
+			}
+			continue
+		}
+		s.snapshots = append(s.snapshots, s.interpreter.Snapshot())
+		if len(s.snapshots) > s.undoLimit {
+			s.snapshots = s.snapshots[len(s.snapshots)-s.undoLimit:]
+		}
+
+		pending = true
+		s.input = []string{line}
+		if s.beforeLine != nil {
+			s.beforeLine(line)
+		}
+	}
+}
+
+// undo restores the interpreter to its state before the last line.
+func (s *Session) undo() error {
+	if len(s.snapshots) == 0 {
+		_, err := fmt.Fprintln(s.errOut, "nothing to undo")
+		return err
+	}
+	s.interpreter.Restore(s.snapshots[len(s.snapshots)-1])
+	s.snapshots = s.snapshots[:len(s.snapshots)-1]
+	return nil
+}
+
+// readLine reads the next line into the interpreter, returning it.
+func (s *Session) readLine() (string, error) {
+	s.reading.Store(true)
+	defer s.reading.Store(false)
+	if err := s.interpreter.Refill(); err != nil {
+		return "", err
+	}
+	return s.last, nil
+}
+
+// Interrupt handles Ctrl-C, interrupting the word being run or, at the
+// prompt, counting towards the two needed to end the session. It returns true
+// when the session should end, for callers handling SIGINT while the session
+// is waiting for a line it can't interrupt.
+func (s *Session) Interrupt() bool {
+	if !s.reading.Load() {
+		s.interpreter.Interrupt()
+		return false
+	}
+	_, err := fmt.Fprintln(s.out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s.idleInterrupt()
+}
+
+func (s *Session) idleInterrupt() bool {
+	if s.interrupts.Add(1) > 1 {
+		return true
+	}
+	_, err := fmt.Fprintln(s.errOut, "(press Ctrl-C again to exit)")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return false
+}
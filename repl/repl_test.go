@@ -0,0 +1,158 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		format         *PromptFormat
+		expectedOutput string
+		expectedErrors string
+	}{
+		"default prompt": {
+			input:          "1 2\n+ .\n",
+			expectedOutput: "ok> 1 2 ok> 3 ok> ",
+		},
+		"depth": {
+			input:          "1 2\n",
+			format:         &PromptFormat{ShowDepth: true, Prompt: "> "},
+			expectedOutput: "<0> > <2> > ",
+		},
+		"custom prompt": {
+			input:          "1\n",
+			format:         &PromptFormat{Prompt: "forth$ "},
+			expectedOutput: "forth$ forth$ ",
+		},
+		"continuation": {
+			input:          ": sq\ndup * ;\n3 sq .\n",
+			expectedOutput: "ok> ...> ok> 9 ok> ",
+		},
+		"undo": {
+			input:          "1 2\n+\nundo\n.S\n",
+			expectedOutput: "ok> 1 2 ok> 3 ok> 1 2 ok> <2> 1 2 1 2 ok> ",
+		},
+		"undo a definition": {
+			input:          ": one 1 ;\n: one 2 ;\nundo\none\n",
+			expectedOutput: "ok> ok> ok> ok> 1 ok> ",
+			expectedErrors: "redefined one\n",
+		},
+		"undo immediate": {
+			input:          ": w 1 ;\nimmediate\nundo\nsee w\n",
+			expectedOutput: "ok> ok> ok> ok> : w 1 ;\nok> ",
+		},
+		"nothing to undo": {
+			input:          "1\nundo\nundo\n",
+			expectedOutput: "ok> 1 ok> ok> ok> ",
+			expectedErrors: "nothing to undo\n",
+		},
+		"key reads the next line": {
+			input:          "key . key .\nAB\n99 .\n",
+			expectedOutput: "ok> 65 66 ok> ok> 99 ok> ",
+		},
+		"accept reads the next line": {
+			input:          "create b 10 allot b 10 accept .\nhello\n77 .\n",
+			expectedOutput: "ok> 5 ok> 77 ok> ",
+		},
+		"debug reads commands from the input": {
+			input:          ": f 1 drop ; debug f\nc\n42 .\n",
+			expectedOutput: "ok> 2: f > 1\n<0>\ndebug> ok> 42 ok> ",
+		},
+		"bye": {
+			input:          "1 .\nbye 2 .\n3 .\n",
+			expectedOutput: "ok> 1 ok> ",
+		},
+		"errors": {
+			input:          "1 nothing\n",
+			expectedOutput: "ok> 1 ok> ",
+			expectedErrors: "nothing ?\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var o, e strings.Builder
+			var options []Option
+			if test.format != nil {
+				options = append(options, WithPrompt(*test.format))
+			}
+			session, err := New(strings.NewReader(test.input), &o, &e, options...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if err := session.Run(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if test.expectedOutput != o.String() {
+				t.Errorf("expected '%v', got '%v'", test.expectedOutput, o.String())
+			}
+			if test.expectedErrors != e.String() {
+				t.Errorf("expected errors '%v', got '%v'", test.expectedErrors, e.String())
+			}
+		})
+	}
+}
+
+func TestSessionHooks(t *testing.T) {
+	var o, e strings.Builder
+	var calls []string
+	session, err := New(strings.NewReader("1\n: two\n2 ;\n"), &o, &e,
+		WithBeforeLine(func(line string) {
+			calls = append(calls, "before "+line)
+		}),
+		WithAfterLine(func(line string) {
+			calls = append(calls, "after "+line)
+		}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := session.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the hooks see the lines a definition continues over
+	expected := "before 1,after 1,before : two,before 2 ;,after : two\n2 ;"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(calls, ","))
+	}
+}
+
+func TestSessionInterruptAtPrompt(t *testing.T) {
+	var o, e strings.Builder
+	session, err := New(strings.NewReader(""), &o, &e)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	session.reading.Store(true)
+
+	if session.Interrupt() {
+		t.Errorf("expected the first interrupt not to end the session")
+	}
+	if !session.Interrupt() {
+		t.Errorf("expected the second interrupt to end the session")
+	}
+	if e.String() != "(press Ctrl-C again to exit)\n" {
+		t.Errorf("expected a hint to press Ctrl-C again, got '%v'", e.String())
+	}
+}
+
+func TestSessionUndoLimit(t *testing.T) {
+	var o, e strings.Builder
+	session, err := New(strings.NewReader("1\n2\n3\nundo\nundo\nundo\n"), &o, &e, WithUndoLimit(2))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := session.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if e.String() != "nothing to undo\n" {
+		t.Errorf("expected only two lines to be undone, got '%v'", e.String())
+	}
+	if stack := session.Interpreter().Stack(); len(stack) != 1 || stack[0] != 1 {
+		t.Errorf("expected [1], got %v", stack)
+	}
+}